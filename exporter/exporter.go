@@ -0,0 +1,78 @@
+/*
+	This file is responsible for exporting parsed data to formats that can be queried without MongoDB.
+*/
+
+package exporter
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// Export formats
+const (
+	EXPORT_SQLITE  = "sqlite"
+	EXPORT_CSV     = "csv"
+	EXPORT_PARQUET = "parquet"
+)
+
+// Grades in the order they appear in a section's grade distribution
+var gradeLabels = []string{"A+", "A", "A-", "B+", "B", "B-", "C+", "C", "C-", "D+", "D", "D-", "F", "W"}
+
+// Grades that are only in the full grade distribution in a section's attributes
+var otherGradeLabels = []string{"CR", "NC", "P", "NF", "I"}
+
+// The parser's output, read back in for exporting
+type dataset struct {
+	courses    []schema.Course
+	sections   []schema.Section
+	professors []schema.Professor
+}
+
+// Externally exposed export function; reads the parsed data files in inDir (in the given data file format) and exports them to outDir in the given export format
+func Export(inDir string, outDir string, exportFormat string, format string) {
+	data, err := loadDataset(inDir, format)
+	if err != nil {
+		log.Panicf("Couldn't load parsed data: %s", err)
+	}
+	log.Printf("Exporting %d courses, %d sections, and %d professors...", len(data.courses), len(data.sections), len(data.professors))
+
+	if err := os.MkdirAll(outDir, 0777); err != nil {
+		panic(err)
+	}
+
+	switch exportFormat {
+	case EXPORT_SQLITE:
+		err = exportSQLite(data, fmt.Sprintf("%s/nebula.db", outDir))
+	case EXPORT_CSV:
+		err = exportCSV(data, fmt.Sprintf("%s/csv", outDir))
+	case EXPORT_PARQUET:
+		err = exportParquet(data, fmt.Sprintf("%s/parquet", outDir))
+	default:
+		log.Panicf("Unknown export format '%s'!", exportFormat)
+	}
+	if err != nil {
+		log.Panicf("Export failed: %s", err)
+	}
+	log.Print("Done exporting!")
+}
+
+func loadDataset(inDir string, format string) (*dataset, error) {
+	courses, err := utils.ReadDataFileAll[schema.Course](utils.DataFilePath(inDir, "courses", format))
+	if err != nil {
+		return nil, err
+	}
+	sections, err := utils.ReadDataFileAll[schema.Section](utils.DataFilePath(inDir, "sections", format))
+	if err != nil {
+		return nil, err
+	}
+	professors, err := utils.ReadDataFileAll[schema.Professor](utils.DataFilePath(inDir, "professors", format))
+	if err != nil {
+		return nil, err
+	}
+	return &dataset{courses: courses, sections: sections, professors: professors}, nil
+}
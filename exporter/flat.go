@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Writes each table to its own CSV file in dir, with a header row of column names
+func exportCSV(data *dataset, dir string) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	files := make(map[string]*os.File, len(tables))
+	writers := make(map[string]*csv.Writer, len(tables))
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, table := range tables {
+		file, err := os.Create(filepath.Join(dir, table.name+".csv"))
+		if err != nil {
+			return err
+		}
+		files[table.name] = file
+		writers[table.name] = csv.NewWriter(file)
+		header := make([]string, 0, len(table.columns))
+		for _, column := range table.columns {
+			header = append(header, column.name)
+		}
+		if err := writers[table.name].Write(header); err != nil {
+			return err
+		}
+	}
+
+	err := writeRows(data, func(table string, values ...interface{}) error {
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = csvValue(value)
+		}
+		return writers[table].Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		writers[table.name].Flush()
+		if err := writers[table.name].Error(); err != nil {
+			return err
+		}
+		if err := files[table.name].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Formats a value for a CSV cell, leaving NULLs empty
+func csvValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// A table's Parquet writer, along with where each of its columns goes in a row
+type parquetTable struct {
+	file          *os.File
+	writer        *parquet.Writer
+	columnIndices []int
+}
+
+// Writes each table to its own Parquet file in dir; every column is optional so NULLs are kept
+func exportParquet(data *dataset, dir string) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	parquetTables := make(map[string]*parquetTable, len(tables))
+	defer func() {
+		for _, parquetTable := range parquetTables {
+			parquetTable.file.Close()
+		}
+	}()
+	for _, table := range tables {
+		group := make(parquet.Group, len(table.columns))
+		for _, column := range table.columns {
+			if strings.HasPrefix(column.sqlType, "INTEGER") {
+				group[column.name] = parquet.Optional(parquet.Int(64))
+			} else {
+				group[column.name] = parquet.Optional(parquet.String())
+			}
+		}
+		schema := parquet.NewSchema(table.name, group)
+
+		// Groups order their columns by name, so look up where each of the table's columns ended up
+		columnIndices := make([]int, len(table.columns))
+		for i, column := range table.columns {
+			leaf, _ := schema.Lookup(column.name)
+			columnIndices[i] = leaf.ColumnIndex
+		}
+
+		file, err := os.Create(filepath.Join(dir, table.name+".parquet"))
+		if err != nil {
+			return err
+		}
+		parquetTables[table.name] = &parquetTable{
+			file:          file,
+			writer:        parquet.NewWriter(file, schema),
+			columnIndices: columnIndices,
+		}
+	}
+
+	err := writeRows(data, func(table string, values ...interface{}) error {
+		parquetTable := parquetTables[table]
+		row := make(parquet.Row, len(values))
+		for i, value := range values {
+			columnIndex := parquetTable.columnIndices[i]
+			if value == nil {
+				row[columnIndex] = parquet.NullValue().Level(0, 0, columnIndex)
+			} else {
+				if number, isInt := value.(int); isInt {
+					value = int64(number)
+				}
+				row[columnIndex] = parquet.ValueOf(value).Level(0, 1, columnIndex)
+			}
+		}
+		_, err := parquetTable.writer.WriteRows([]parquet.Row{row})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		parquetTable := parquetTables[table.name]
+		if err := parquetTable.writer.Close(); err != nil {
+			return err
+		}
+		if err := parquetTable.file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
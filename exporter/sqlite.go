@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Makes the statements that create the tables and their indexes
+func sqliteSchema() string {
+	var builder strings.Builder
+	for _, table := range tables {
+		definitions := make([]string, 0, len(table.columns)+len(table.constraints))
+		for _, column := range table.columns {
+			definitions = append(definitions, fmt.Sprintf("%s %s", column.name, column.sqlType))
+		}
+		definitions = append(definitions, table.constraints...)
+		fmt.Fprintf(&builder, "CREATE TABLE %s (\n\t%s\n);\n", table.name, strings.Join(definitions, ",\n\t"))
+		for _, index := range table.indexes {
+			fmt.Fprintf(&builder, "%s;\n", index)
+		}
+	}
+	return builder.String()
+}
+
+// Writes the dataset to a new SQLite database at dbPath, replacing any existing one
+func exportSQLite(data *dataset, dbPath string) error {
+	if err := os.Remove(dbPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema()); err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+
+	// Insert everything in one transaction, which is far faster than committing each row
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	inserts := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, stmt := range inserts {
+			stmt.Close()
+		}
+	}()
+	err = writeRows(data, func(table string, values ...interface{}) error {
+		stmt, exists := inserts[table]
+		if !exists {
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+			stmt, err = tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", table, placeholders))
+			if err != nil {
+				return err
+			}
+			inserts[table] = stmt
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return fmt.Errorf("inserting into %s: %w", table, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,383 @@
+package exporter
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// A column of an exported table, with its SQLite type and constraints
+type column struct {
+	name    string
+	sqlType string
+}
+
+// A flat table of exported data. Requisites and attributes are nested structures, so they're stored as JSON text.
+type table struct {
+	name        string
+	columns     []column
+	constraints []string
+	indexes     []string
+}
+
+// Every exported table, in the order rows are written. Rows are written with their values in the same order as the table's columns.
+var tables = []table{
+	{
+		name: "courses",
+		columns: []column{
+			{"id", "TEXT PRIMARY KEY"},
+			{"subject_prefix", "TEXT NOT NULL"},
+			{"course_number", "TEXT NOT NULL"},
+			{"title", "TEXT"},
+			{"description", "TEXT"},
+			{"enrollment_reqs", "TEXT"},
+			{"school", "TEXT"},
+			{"credit_hours", "TEXT"},
+			{"class_level", "TEXT"},
+			{"activity_type", "TEXT"},
+			{"grading", "TEXT"},
+			{"internal_course_number", "TEXT"},
+			{"lecture_contact_hours", "TEXT"},
+			{"laboratory_contact_hours", "TEXT"},
+			{"offering_frequency", "TEXT"},
+			{"catalog_year", "TEXT"},
+			{"prerequisites", "TEXT"},
+			{"corequisites", "TEXT"},
+			{"co_or_pre_requisites", "TEXT"},
+			{"attributes", "TEXT"},
+		},
+		indexes: []string{
+			"CREATE INDEX courses_subject_number ON courses (subject_prefix, course_number)",
+		},
+	},
+	{
+		name: "professors",
+		columns: []column{
+			{"id", "TEXT PRIMARY KEY"},
+			{"first_name", "TEXT"},
+			{"last_name", "TEXT"},
+			{"email", "TEXT"},
+			{"phone_number", "TEXT"},
+			{"office_building", "TEXT"},
+			{"office_room", "TEXT"},
+			{"office_map_uri", "TEXT"},
+			{"profile_uri", "TEXT"},
+			{"image_uri", "TEXT"},
+		},
+		indexes: []string{
+			"CREATE INDEX professors_name ON professors (last_name, first_name)",
+		},
+	},
+	{
+		name: "professor_titles",
+		columns: []column{
+			{"professor_id", "TEXT NOT NULL REFERENCES professors (id)"},
+			{"title", "TEXT NOT NULL"},
+		},
+	},
+	{
+		name: "sections",
+		columns: []column{
+			{"id", "TEXT PRIMARY KEY"},
+			{"course_id", "TEXT NOT NULL REFERENCES courses (id)"},
+			{"section_number", "TEXT NOT NULL"},
+			{"term", "TEXT NOT NULL"},
+			{"term_start_date", "TEXT"},
+			{"term_end_date", "TEXT"},
+			{"internal_class_number", "TEXT"},
+			{"instruction_mode", "TEXT"},
+			{"syllabus_uri", "TEXT"},
+			{"section_corequisites", "TEXT"},
+			{"attributes", "TEXT"},
+		},
+		indexes: []string{
+			"CREATE INDEX sections_course ON sections (course_id)",
+			"CREATE INDEX sections_term ON sections (term)",
+		},
+	},
+	{
+		name: "section_professors",
+		columns: []column{
+			{"section_id", "TEXT NOT NULL REFERENCES sections (id)"},
+			{"professor_id", "TEXT NOT NULL REFERENCES professors (id)"},
+		},
+		constraints: []string{
+			"PRIMARY KEY (section_id, professor_id)",
+		},
+		indexes: []string{
+			"CREATE INDEX section_professors_professor ON section_professors (professor_id)",
+		},
+	},
+	{
+		name: "teaching_assistants",
+		columns: []column{
+			{"section_id", "TEXT NOT NULL REFERENCES sections (id)"},
+			{"first_name", "TEXT"},
+			{"last_name", "TEXT"},
+			{"role", "TEXT"},
+			{"email", "TEXT"},
+		},
+	},
+	{
+		name: "meetings",
+		columns: []column{
+			{"section_id", "TEXT NOT NULL REFERENCES sections (id)"},
+			{"meeting_index", "INTEGER NOT NULL"},
+			{"start_date", "TEXT"},
+			{"end_date", "TEXT"},
+			{"start_time", "TEXT"},
+			{"end_time", "TEXT"},
+			{"modality", "TEXT"},
+			{"building", "TEXT"},
+			{"room", "TEXT"},
+			{"map_uri", "TEXT"},
+		},
+		constraints: []string{
+			"PRIMARY KEY (section_id, meeting_index)",
+		},
+		indexes: []string{
+			"CREATE INDEX meetings_room ON meetings (building, room)",
+		},
+	},
+	{
+		name: "meeting_days",
+		columns: []column{
+			{"section_id", "TEXT NOT NULL"},
+			{"meeting_index", "INTEGER NOT NULL"},
+			{"day", "TEXT NOT NULL"},
+		},
+		constraints: []string{
+			"FOREIGN KEY (section_id, meeting_index) REFERENCES meetings (section_id, meeting_index)",
+		},
+	},
+	{
+		name: "core_flags",
+		columns: []column{
+			{"section_id", "TEXT NOT NULL REFERENCES sections (id)"},
+			{"core_flag", "TEXT NOT NULL"},
+		},
+		constraints: []string{
+			"PRIMARY KEY (section_id, core_flag)",
+		},
+	},
+	{
+		name: "grade_distributions",
+		columns: []column{
+			{"section_id", "TEXT NOT NULL REFERENCES sections (id)"},
+			{"grade", "TEXT NOT NULL"},
+			{"count", "INTEGER NOT NULL"},
+		},
+		constraints: []string{
+			"PRIMARY KEY (section_id, grade)",
+		},
+	},
+}
+
+// Receives each row of the exported data for the named table
+type rowSink func(table string, values ...interface{}) error
+
+// Flattens the dataset into rows, sending each to sink
+func writeRows(data *dataset, sink rowSink) error {
+	for _, course := range data.courses {
+		if err := writeCourseRows(sink, &course); err != nil {
+			return err
+		}
+	}
+	for _, professor := range data.professors {
+		if err := writeProfessorRows(sink, &professor); err != nil {
+			return err
+		}
+	}
+	for _, section := range data.sections {
+		if err := writeSectionRows(sink, &section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCourseRows(sink rowSink, course *schema.Course) error {
+	prerequisites, err := jsonColumn(course.Prerequisites)
+	if err != nil {
+		return err
+	}
+	corequisites, err := jsonColumn(course.Corequisites)
+	if err != nil {
+		return err
+	}
+	coOrPrerequisites, err := jsonColumn(course.Co_or_pre_requisites)
+	if err != nil {
+		return err
+	}
+	attributes, err := jsonColumn(course.Attributes)
+	if err != nil {
+		return err
+	}
+	return sink("courses",
+		course.Id.Hex(),
+		course.Subject_prefix,
+		course.Course_number,
+		course.Title,
+		course.Description,
+		course.Enrollment_reqs,
+		course.School,
+		course.Credit_hours,
+		course.Class_level,
+		course.Activity_type,
+		course.Grading,
+		course.Internal_course_number,
+		course.Lecture_contact_hours,
+		course.Laboratory_contact_hours,
+		course.Offering_frequency,
+		course.Catalog_year,
+		prerequisites,
+		corequisites,
+		coOrPrerequisites,
+		attributes,
+	)
+}
+
+func writeProfessorRows(sink rowSink, professor *schema.Professor) error {
+	err := sink("professors",
+		professor.Id.Hex(),
+		professor.First_name,
+		professor.Last_name,
+		professor.Email,
+		professor.Phone_number,
+		professor.Office.Building,
+		professor.Office.Room,
+		professor.Office.Map_uri,
+		professor.Profile_uri,
+		professor.Image_uri,
+	)
+	if err != nil {
+		return err
+	}
+	for _, title := range professor.Titles {
+		if err := sink("professor_titles", professor.Id.Hex(), title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSectionRows(sink rowSink, section *schema.Section) error {
+	sectionId := section.Id.Hex()
+	corequisites, err := jsonColumn(section.Section_corequisites)
+	if err != nil {
+		return err
+	}
+	attributes, err := jsonColumn(section.Attributes)
+	if err != nil {
+		return err
+	}
+	err = sink("sections",
+		sectionId,
+		section.Course_reference.Hex(),
+		section.Section_number,
+		section.Academic_session.Name,
+		dateColumn(section.Academic_session.Start_date),
+		dateColumn(section.Academic_session.End_date),
+		section.Internal_class_number,
+		section.Instruction_mode,
+		section.Syllabus_uri,
+		corequisites,
+		attributes,
+	)
+	if err != nil {
+		return err
+	}
+
+	// The same professor could be listed twice, so only keep the first
+	seenProfessors := make(map[string]bool, len(section.Professors))
+	for _, profId := range section.Professors {
+		if seenProfessors[profId.Hex()] {
+			continue
+		}
+		seenProfessors[profId.Hex()] = true
+		if err := sink("section_professors", sectionId, profId.Hex()); err != nil {
+			return err
+		}
+	}
+	for _, assistant := range section.Teaching_assistants {
+		if err := sink("teaching_assistants", sectionId, assistant.First_name, assistant.Last_name, assistant.Role, assistant.Email); err != nil {
+			return err
+		}
+	}
+	for i, meeting := range section.Meetings {
+		err := sink("meetings",
+			sectionId,
+			i,
+			dateColumn(meeting.Start_date),
+			dateColumn(meeting.End_date),
+			meeting.Start_time,
+			meeting.End_time,
+			meeting.Modality,
+			meeting.Location.Building,
+			meeting.Location.Room,
+			meeting.Location.Map_uri,
+		)
+		if err != nil {
+			return err
+		}
+		for _, day := range meeting.Meeting_days {
+			if err := sink("meeting_days", sectionId, i, day); err != nil {
+				return err
+			}
+		}
+	}
+	seenFlags := make(map[string]bool, len(section.Core_flags))
+	for _, coreFlag := range section.Core_flags {
+		if seenFlags[coreFlag] {
+			continue
+		}
+		seenFlags[coreFlag] = true
+		if err := sink("core_flags", sectionId, coreFlag); err != nil {
+			return err
+		}
+	}
+	for i, count := range section.Grade_distribution {
+		if i >= len(gradeLabels) {
+			break
+		}
+		if err := sink("grade_distributions", sectionId, gradeLabels[i], count); err != nil {
+			return err
+		}
+	}
+	// Sections with a full grade distribution also have the grades Grade_distribution leaves out
+	if attributes, hasAttributes := section.Attributes.(map[string]interface{}); hasAttributes {
+		if grades, hasGrades := attributes["grades"].(map[string]interface{}); hasGrades {
+			for _, grade := range otherGradeLabels {
+				count, _ := grades[grade].(float64)
+				if err := sink("grade_distributions", sectionId, grade, int(count)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Encodes a nested value as JSON text, or NULL if there's nothing to encode
+func jsonColumn(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(encoded) == "null" {
+		return nil, nil
+	}
+	return string(encoded), nil
+}
+
+// Formats a date as YYYY-MM-DD, or NULL if it isn't set
+func dateColumn(date time.Time) interface{} {
+	if date.IsZero() {
+		return nil
+	}
+	return date.Format("2006-01-02")
+}
@@ -0,0 +1,188 @@
+/*
+	This file contains the code for linking scraped events to the student organizations that (probably) host them.
+
+	Each event is matched against every organization by contact email, contact name, department, and mentions of
+	the organization's title or acronym, and every match is recorded with a confidence level and the reasons for it.
+*/
+
+package linker
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Confidence levels for event-organization links, in increasing order
+const (
+	CONFIDENCE_LOW    = "low"
+	CONFIDENCE_MEDIUM = "medium"
+	CONFIDENCE_HIGH   = "high"
+)
+
+var confidenceRanks = map[string]int{CONFIDENCE_LOW: 1, CONFIDENCE_MEDIUM: 2, CONFIDENCE_HIGH: 3}
+
+// Email domains shared by too many people to say anything about who hosts an event
+var commonEmailDomains = map[string]bool{
+	"utdallas.edu": true,
+	"gmail.com":    true,
+	"outlook.com":  true,
+	"hotmail.com":  true,
+	"yahoo.com":    true,
+	"icloud.com":   true,
+}
+
+// Organization titles shorter than this are too ambiguous to look for in event text
+const MIN_TITLE_MENTION_LENGTH = 4
+
+// Reference from an event to the organization that (probably) hosts it
+type EventOrganization struct {
+	Event        primitive.ObjectID `bson:"event" json:"event"`
+	Organization primitive.ObjectID `bson:"organization" json:"organization"`
+	Confidence   string             `bson:"confidence" json:"confidence"`
+	Reasons      []string           `bson:"reasons" json:"reasons"`
+}
+
+// Organization info precomputed for matching
+type orgMatcher struct {
+	org           *schema.Organization
+	emails        map[string]bool
+	domains       map[string]bool
+	president     string
+	title         string
+	titleRegexp   *regexp.Regexp
+	acronymRegexp *regexp.Regexp
+}
+
+// Regex for pulling a parenthesized acronym out of an organization title, i.e. "Association for Computing Machinery (ACM)"
+var orgAcronymRegexp *regexp.Regexp = regexp.MustCompile(`\(([A-Z][A-Za-z0-9&]{1,9})\)`)
+
+// Externally exposed event linking function; reads the events and organizations data files from inDir and writes event_organizations to outDir
+func LinkEvents(inDir string, outDir string, format string) {
+	events, err := utils.ReadDataFileAll[schema.Event](utils.DataFilePath(inDir, "events", format))
+	if err != nil {
+		log.Panicf("Couldn't load events: %s", err)
+	}
+	orgs, err := utils.ReadDataFileAll[schema.Organization](utils.DataFilePath(inDir, "organizations", format))
+	if err != nil {
+		log.Panicf("Couldn't load organizations: %s", err)
+	}
+	log.Printf("Linking %d events to %d organizations...", len(events), len(orgs))
+
+	links := linkEventsToOrgs(events, orgs)
+
+	linkedEvents := make(map[primitive.ObjectID]bool)
+	for _, link := range links {
+		linkedEvents[link.Event] = true
+	}
+	log.Printf("Made %d links, covering %d of %d events.", len(links), len(linkedEvents), len(events))
+
+	if err := os.MkdirAll(outDir, 0777); err != nil {
+		panic(err)
+	}
+	if err := utils.WriteDataFile(outDir, "event_organizations", format, links); err != nil {
+		panic(err)
+	}
+}
+
+// Matches each event against every organization, returning one link per matching event-organization pair
+func linkEventsToOrgs(events []schema.Event, orgs []schema.Organization) []EventOrganization {
+	matchers := make([]orgMatcher, 0, len(orgs))
+	for i := range orgs {
+		matchers = append(matchers, newOrgMatcher(&orgs[i]))
+	}
+
+	links := []EventOrganization{}
+	for _, event := range events {
+		contactEmail := strings.ToLower(utils.TrimWhitespace(event.ContactEmail))
+		_, contactDomain, _ := strings.Cut(contactEmail, "@")
+		contactName := normalizeName(event.ContactName)
+		eventText := event.Summary + "\n" + event.Description
+
+		eventLinks := []EventOrganization{}
+		for _, matcher := range matchers {
+			var reasons []string
+			confidence := ""
+			addReason := func(reason string, level string) {
+				reasons = append(reasons, reason)
+				if confidenceRanks[level] > confidenceRanks[confidence] {
+					confidence = level
+				}
+			}
+
+			if contactEmail != "" && matcher.emails[contactEmail] {
+				addReason("contact email matches organization email", CONFIDENCE_HIGH)
+			} else if contactDomain != "" && !commonEmailDomains[contactDomain] && matcher.domains[contactDomain] {
+				addReason("contact email domain matches organization email domain", CONFIDENCE_MEDIUM)
+			}
+			if contactName != "" && contactName == matcher.president {
+				addReason("contact name matches organization president", CONFIDENCE_MEDIUM)
+			}
+			for _, department := range event.Department {
+				if strings.EqualFold(utils.TrimWhitespace(department), matcher.title) {
+					addReason("event department matches organization title", CONFIDENCE_HIGH)
+					break
+				}
+			}
+			if matcher.titleRegexp != nil && matcher.titleRegexp.MatchString(eventText) {
+				addReason("event mentions organization title", CONFIDENCE_LOW)
+			} else if matcher.acronymRegexp != nil && matcher.acronymRegexp.MatchString(eventText) {
+				addReason("event mentions organization acronym", CONFIDENCE_LOW)
+			}
+
+			if len(reasons) > 0 {
+				eventLinks = append(eventLinks, EventOrganization{
+					Event:        event.Id,
+					Organization: matcher.org.Id,
+					Confidence:   confidence,
+					Reasons:      reasons,
+				})
+			}
+		}
+
+		// Strongest links first for each event
+		sort.SliceStable(eventLinks, func(i, j int) bool {
+			return confidenceRanks[eventLinks[i].Confidence] > confidenceRanks[eventLinks[j].Confidence]
+		})
+		links = append(links, eventLinks...)
+	}
+	return links
+}
+
+func newOrgMatcher(org *schema.Organization) orgMatcher {
+	matcher := orgMatcher{
+		org:       org,
+		emails:    make(map[string]bool, len(org.Emails)),
+		domains:   make(map[string]bool, len(org.Emails)),
+		president: normalizeName(org.President_name),
+		title:     utils.TrimWhitespace(org.Title),
+	}
+	for _, email := range org.Emails {
+		email = strings.ToLower(utils.TrimWhitespace(email))
+		matcher.emails[email] = true
+		if _, domain, found := strings.Cut(email, "@"); found {
+			matcher.domains[domain] = true
+		}
+	}
+	// Look for the title without its acronym, and the acronym on its own
+	title := utils.TrimWhitespace(orgAcronymRegexp.ReplaceAllString(matcher.title, ""))
+	if len(title) >= MIN_TITLE_MENTION_LENGTH {
+		matcher.titleRegexp = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(title) + `\b`)
+	}
+	if acronymMatch := orgAcronymRegexp.FindStringSubmatch(matcher.title); acronymMatch != nil && len(acronymMatch[1]) >= 3 {
+		// Acronyms are matched case-sensitively to avoid matching ordinary words
+		matcher.acronymRegexp = regexp.MustCompile(`\b` + regexp.QuoteMeta(acronymMatch[1]) + `\b`)
+	}
+	return matcher
+}
+
+// Normalizes a person's name for comparison by lowercasing it and collapsing whitespace
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/UTDNebula/api-tools/exporter"
+	"github.com/UTDNebula/api-tools/linker"
 	"github.com/UTDNebula/api-tools/parser"
+	"github.com/UTDNebula/api-tools/schemas"
 	"github.com/UTDNebula/api-tools/scrapers"
 	"github.com/UTDNebula/api-tools/uploader"
 	"github.com/UTDNebula/api-tools/utils"
@@ -21,6 +25,7 @@ func main() {
 	inDir := flag.String("i", "./data", "The directory to read data from. Defaults to ./data.")
 	outDir := flag.String("o", "./data", "The directory to write resulting data to. Defaults to ./data.")
 	logDir := flag.String("l", "./logs", "The directory to write logs to. Defaults to ./logs.")
+	format := flag.String("format", utils.FORMAT_JSON, "The format of the data files to write when scraping or parsing, and to read when parsing or uploading: \"json\" for a single JSON array per file, or \"ndjson\" for one JSON document per line. Defaults to json.")
 
 	// Flags for all scraping
 	scrape := flag.Bool("scrape", false, "Puts the tool into scraping mode.")
@@ -36,6 +41,10 @@ func main() {
 	scrapeOrganizations := flag.Bool("organizations", false, "Alongside -scrape, signifies that SOC organizations should be scraped.")
 	// Flag for event scraping
 	scrapeEvents := flag.Bool("events", false, "Alongside -scrape, signifies that events should be scraped.")
+	eventStart := flag.String("start", "", "Alongside -events, specifies the first day of events to scrape in YYYY-MM-DD format. Defaults to today.")
+	eventEnd := flag.String("end", "", "Alongside -events, specifies the last day of events to scrape in YYYY-MM-DD format. Defaults to 30 days after -start.")
+	eventSource := flag.String("eventsource", "json", "Alongside -events, specifies where events are read from: the calendar's \"json\" or \"ics\" feed, or \"dom\" to scrape the event pages. Feeds fall back to scraping the event pages if they fail.")
+	eventFeed := flag.String("eventfeed", "", "Alongside -events, specifies a URL or saved file to read the event feed from instead of the live calendar.")
 	// Flag for astra scraping
 	scrapeAstra := flag.Bool("astra", false, "Alongside -scrape, signifies that Astra should be scraped.")
 
@@ -43,10 +52,31 @@ func main() {
 	parse := flag.Bool("parse", false, "Puts the tool into parsing mode.")
 	csvDir := flag.String("csv", "./grade-data", "Alongside -parse, specifies the path to the directory of CSV files containing grade data.")
 	skipValidation := flag.Bool("skipv", false, "Alongside -parse, signifies that the post-parsing validation should be skipped. Be careful with this!")
+	maxFailures := flag.Int("maxfailures", 0, "Alongside -parse, specifies how many files may fail to parse before the tool exits with an error. Failed files are listed in parse_report.json either way.")
+	dropCancelled := flag.Bool("dropcancelled", false, "Alongside -parse, signifies that cancelled sections should be dropped instead of only being flagged by validation, even if validation is skipped. Professors who only taught cancelled sections are dropped along with them.")
+	terms := flag.String("terms", "", "Alongside -parse, specifies a comma-separated list of terms to parse, i.e. 23F,24S. Defaults to all terms.")
+	perTerm := flag.Bool("perterm", false, "Alongside -parse, signifies that each term's sections should also be written to their own directory under terms/, along with the courses and professors they reference, so a single term can be uploaded with -termsubset.")
+	gradeOnly := flag.Bool("gradeonly", false, "Alongside -parse, signifies that sections should be created from the grade data alone for terms that weren't scraped from coursebook, so grade history goes back further than the scraped data.")
+	gpaWeights := flag.String("gpaweights", "", "Alongside -parse, specifies a JSON file mapping grades to the GPA points used for grade statistics, i.e. {\"A\": 4.0, \"A-\": 3.67, ...}. Defaults to UTD's GPA points for letter grades.")
+	minCohort := flag.Int("mincohort", 0, "Alongside -parse, specifies the fewest students a grade distribution may have to be published as is; smaller ones are handled as -suppress says, and smaller grade statistics are left out. Held back grades are listed in grade_report.json. Defaults to 0, publishing every distribution.")
+	suppressionMode := flag.String("suppress", parser.SUPPRESS_DROP, fmt.Sprintf("Alongside -mincohort, specifies what's done with grade distributions with too few students: \"suppress\" to leave them out, or \"coarsen\" to fold plus and minus letter grades into their base letter grade. Distributions with fewer than %d students are always left out, since coarsening them would still show their students' grades. Defaults to suppress.", parser.MIN_COARSENED_COHORT))
+	cacheDir := flag.String("cache", "", "Alongside -parse, specifies a directory to cache what's read from each page in, so that unchanged pages aren't read again on later runs.")
+
+	// Flag for linking events
+	linkEvents := flag.Bool("linkevents", false, "Puts the tool into event linking mode, linking the events in -i to the student organizations in -i that host them, and writing the links to -o.")
 
 	// Flags for uploading data
 	upload := flag.Bool("upload", false, "Puts the tool into upload mode.")
-	replace := flag.Bool("replace", false, "Alongside -upload, specifies that uploaded data should replace existing data rather than being merged.")
+	replace := flag.Bool("replace", false, "Alongside -upload, specifies that uploaded data should replace existing data rather than being merged. This is required the first time data with deterministic IDs is uploaded over data that was uploaded with random IDs, since merging would duplicate every section.")
+	termSubset := flag.Bool("termsubset", false, "Alongside -upload, signifies that the data only covers some terms (i.e. it was parsed with -terms, or is a directory written by -perterm), so the sections listed by uploaded courses and professors are added to their existing sections rather than replacing them.")
+
+	// Flags for exporting data
+	export := flag.Bool("export", false, "Puts the tool into export mode, exporting parsed data from -i to -o.")
+	exportFormat := flag.String("exportformat", exporter.EXPORT_SQLITE, "Alongside -export, specifies the format to export to: \"sqlite\" for a single SQLite database, or \"csv\" or \"parquet\" for a file per table. Defaults to sqlite.")
+
+	// Flags for data file schemas
+	schema := flag.Bool("schema", false, "Puts the tool into schema mode, writing a JSON Schema for each data file the tool produces to -o/schemas.")
+	checkSchema := flag.Bool("check-schema", false, "Checks every data file in -i (and its subdirectories) against its JSON Schema, reporting each violation with a JSON pointer.")
 
 	// Flags for logging
 	verbose := flag.Bool("verbose", false, "Enables verbose logging, good for debugging purposes.")
@@ -79,30 +109,69 @@ func main() {
 		log.SetFlags(log.Ltime)
 	}
 
+	if err := utils.ValidateFormat(*format); err != nil {
+		log.Fatal(err)
+	}
+
 	// Perform actions based on flags
 	switch {
 	case *scrape:
 		switch {
 		case *scrapeProfiles:
-			scrapers.ScrapeProfiles(*outDir)
+			scrapers.ScrapeProfiles(*outDir, *format)
 		case *scrapeCoursebook:
 			if *term == "" {
 				log.Panic("No term specified for coursebook scraping! Use -term to specify.")
 			}
 			scrapers.ScrapeCoursebook(*term, *startPrefix, *outDir)
 		case *scrapeOrganizations:
-			scrapers.ScrapeOrganizations(*outDir)
+			scrapers.ScrapeOrganizations(*outDir, *format)
 		case *scrapeEvents:
-			scrapers.ScrapeEvents(*outDir)
+			scrapers.ScrapeEvents(*outDir, *eventStart, *eventEnd, *eventSource, *eventFeed, *format)
 		case *scrapeAstra:
-			scrapers.ScrapeAstra(*outDir)
+			scrapers.ScrapeAstra(*outDir, *format)
 		default:
 			log.Panic("You must specify which type of scraping you would like to perform with one of the scraping flags!")
 		}
 	case *parse:
-		parser.Parse(*inDir, *outDir, *csvDir, *skipValidation)
+		parseOpts := []parser.Option{
+			parser.WithGrades(*csvDir),
+			parser.WithValidation(!*skipValidation),
+			parser.WithMaxFailures(*maxFailures),
+			parser.WithDropCancelled(*dropCancelled),
+			parser.WithCache(*cacheDir),
+			parser.WithTerms(strings.Split(*terms, ",")...),
+			parser.WithPerTermOutput(*perTerm),
+			parser.WithFormat(*format),
+			parser.WithGradeOnlySections(*gradeOnly),
+		}
+		if *minCohort > 0 {
+			if err := parser.ValidateSuppressionMode(*suppressionMode); err != nil {
+				log.Fatal(err)
+			}
+			parseOpts = append(parseOpts, parser.WithMinCohort(*minCohort, *suppressionMode))
+		}
+		if *gpaWeights != "" {
+			weights, err := parser.ReadGPAWeights(*gpaWeights)
+			if err != nil {
+				log.Fatal(err)
+			}
+			parseOpts = append(parseOpts, parser.WithGPAWeights(weights))
+		}
+		parser.Parse(*inDir, *outDir, parseOpts...)
+	case *linkEvents:
+		linker.LinkEvents(*inDir, *outDir, *format)
 	case *upload:
-		uploader.Upload(*inDir, *replace)
+		if *replace && *termSubset {
+			log.Fatal("-replace and -termsubset can't be used together, since replacing would delete every other term's data.")
+		}
+		uploader.Upload(*inDir, *replace, *termSubset, *format)
+	case *export:
+		exporter.Export(*inDir, *outDir, *exportFormat, *format)
+	case *schema:
+		schemas.Generate(fmt.Sprintf("%s/schemas", *outDir))
+	case *checkSchema:
+		schemas.Check(*inDir)
 	default:
 		flag.PrintDefaults()
 		return
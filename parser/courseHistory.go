@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Extra course data that schema.Course has no fields for, stored in the course's Attributes
+type CourseAttributes struct {
+	// Versions of the same course from the previous and next catalog years that were parsed
+	Previous_version *primitive.ObjectID `bson:"previous_version,omitempty" json:"previous_version,omitempty"`
+	Next_version     *primitive.ObjectID `bson:"next_version,omitempty" json:"next_version,omitempty"`
+	// What changed since the previous version
+	Changes []FieldChange `bson:"changes,omitempty" json:"changes,omitempty"`
+}
+
+// A change to one of a course's fields between catalog years
+type FieldChange struct {
+	Field    string `bson:"field" json:"field"`
+	Previous string `bson:"previous" json:"previous"`
+	Current  string `bson:"current" json:"current"`
+}
+
+// Gets the course's attributes, creating them if the course doesn't have any yet
+func getCourseAttributes(course *schema.Course) *CourseAttributes {
+	attributes, ok := course.Attributes.(*CourseAttributes)
+	if !ok {
+		attributes = &CourseAttributes{}
+		course.Attributes = attributes
+	}
+	return attributes
+}
+
+// Links the versions of each course across catalog years, and records what changed between consecutive versions; must be run after course requisites are parsed
+func (p *Parser) linkCourseVersions() {
+	// Courses keep their internal course number across catalog years
+	versions := make(map[string][]*schema.Course)
+	catalogYears := make(map[*schema.Course]int, len(p.courses))
+	for _, course := range p.courses {
+		catalogYear, ok := catalogYearNumber(course)
+		if !ok {
+			log.Printf("WARN: Course %s%s has a non-numeric catalog year '%s', so it won't be linked to its other versions!", course.Subject_prefix, course.Course_number, course.Catalog_year)
+			continue
+		}
+		catalogYears[course] = catalogYear
+		versions[course.Internal_course_number] = append(versions[course.Internal_course_number], course)
+	}
+
+	linkedCourses := 0
+	for _, courseVersions := range versions {
+		if len(courseVersions) < 2 {
+			continue
+		}
+		sort.Slice(courseVersions, func(i, j int) bool {
+			return catalogYears[courseVersions[i]] < catalogYears[courseVersions[j]]
+		})
+		for i := 1; i < len(courseVersions); i++ {
+			previous, current := courseVersions[i-1], courseVersions[i]
+			getCourseAttributes(previous).Next_version = &current.Id
+			currentAttributes := getCourseAttributes(current)
+			currentAttributes.Previous_version = &previous.Id
+			currentAttributes.Changes = diffCourses(previous, current)
+		}
+		linkedCourses++
+	}
+	log.Printf("Linked versions of %d courses across catalog years.", linkedCourses)
+}
+
+// Gets the course's catalog year as a number, reporting false if it isn't one
+func catalogYearNumber(course *schema.Course) (int, bool) {
+	year, err := strconv.Atoi(course.Catalog_year)
+	return year, err == nil
+}
+
+// Compares the fields of two versions of a course that consumers care about changing
+func diffCourses(previous *schema.Course, current *schema.Course) []FieldChange {
+	type comparedField struct {
+		name     string
+		previous string
+		current  string
+	}
+	fields := []comparedField{
+		{"title", previous.Title, current.Title},
+		{"description", previous.Description, current.Description},
+		{"credit_hours", previous.Credit_hours, current.Credit_hours},
+	}
+	// Parsed requisites are structures that reference courses by their internal course number, so compare the enrollment reqs they came from instead.
+	// Courses without enrollment reqs take their requisites from their description, whose changes are already reported, so only compare them when both versions list them.
+	if previous.Enrollment_reqs != "" && current.Enrollment_reqs != "" {
+		fields = append(fields, comparedField{"requisites", previous.Enrollment_reqs, current.Enrollment_reqs})
+	}
+	var changes []FieldChange
+	for _, field := range fields {
+		if utils.TrimWhitespace(field.previous) != utils.TrimWhitespace(field.current) {
+			changes = append(changes, FieldChange{Field: field.name, Previous: field.previous, Current: field.current})
+		}
+	}
+	return changes
+}
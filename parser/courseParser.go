@@ -7,7 +7,6 @@ import (
 
 	"github.com/UTDNebula/api-tools/utils"
 	"github.com/UTDNebula/nebula-api/api/schema"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var coursePrefixRexp *regexp.Regexp = utils.Regexpf(`^%s`, utils.R_SUBJ_COURSE_CAP)
@@ -31,13 +30,13 @@ func getCatalogYear(session schema.AcademicSession) string {
 	}
 }
 
-func parseCourse(courseNum string, session schema.AcademicSession, rowInfo map[string]string, classInfo map[string]string) *schema.Course {
+func (p *Parser) parseCourse(courseNum string, session schema.AcademicSession, rowInfo map[string]string, classInfo map[string]string) *schema.Course {
 	// Courses are internally keyed by their internal course number and the catalog year they're part of
 	catalogYear := getCatalogYear(session)
 	courseKey := courseNum + catalogYear
 
 	// Don't recreate the course if it already exists
-	course, courseExists := Courses[courseKey]
+	course, courseExists := p.courses[courseKey]
 	if courseExists {
 		return course
 	}
@@ -48,7 +47,8 @@ func parseCourse(courseNum string, session schema.AcademicSession, rowInfo map[s
 
 	course = &schema.Course{}
 
-	course.Id = primitive.NewObjectID()
+	// IDs are derived from the course key so they stay the same across runs
+	course.Id = utils.DeterministicID("course", courseNum, catalogYear)
 	course.Course_number = idMatches[2]
 	course.Subject_prefix = idMatches[1]
 	course.Title = rowInfo["Course Title:"]
@@ -62,7 +62,7 @@ func parseCourse(courseNum string, session schema.AcademicSession, rowInfo map[s
 
 	// Get closure for parsing course requisites (god help me)
 	enrollmentReqs, hasEnrollmentReqs := rowInfo["Enrollment Reqs:"]
-	ReqParsers[course.Id] = getReqParser(course, hasEnrollmentReqs, enrollmentReqs)
+	p.reqParsers[course.Id] = p.getReqParser(course, hasEnrollmentReqs, enrollmentReqs)
 
 	// Try to get lecture/lab contact hours and offering frequency from course description
 	contactMatches := contactRegexp.FindStringSubmatch(course.Description)
@@ -76,7 +76,12 @@ func parseCourse(courseNum string, session schema.AcademicSession, rowInfo map[s
 	// Set the catalog year
 	course.Catalog_year = catalogYear
 
-	Courses[courseKey] = course
-	CourseIDMap[course.Id] = courseKey
+	p.courses[courseKey] = course
+	p.courseIDMap[course.Id] = courseKey
+	p.pageUndo = append(p.pageUndo, func() {
+		delete(p.courses, courseKey)
+		delete(p.courseIDMap, course.Id)
+		delete(p.reqParsers, course.Id)
+	})
 	return course
 }
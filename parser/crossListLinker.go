@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Section IDs listed in a page's cross-list field, i.e. "SE 4352.001" or "SE4352.001.23F"
+var crossListRegexp *regexp.Regexp = utils.Regexpf(`%s\.(%s)`, utils.R_SUBJ_COURSE_CAP, utils.R_SECTION_CODE)
+
+// Gets the sections a page says its section is cross-listed with, as section keys for the given term
+func getCrossListKeys(term string, rowInfo map[string]string, classInfo map[string]string) []string {
+	labels := []string{"Cross-Listed:", "Cross Listed:", "Cross-listed With:", "Cross Listed With:", "Crosslisted:"}
+	crossListText, exists := getClassInfo(classInfo, labels...)
+	if !exists {
+		crossListText, exists = getClassInfo(rowInfo, labels...)
+	}
+	if !exists {
+		return nil
+	}
+	var keys []string
+	for _, match := range crossListRegexp.FindAllStringSubmatch(crossListText, -1) {
+		keys = append(keys, sectionKey(match[1], match[2], match[3], term))
+	}
+	return keys
+}
+
+// Makes a key identifying a section by its subject, course number, section number, and term, i.e. "SE4352.001.23F"
+func sectionKey(subject string, courseNumber string, sectionNumber string, term string) string {
+	return strings.ToUpper(fmt.Sprintf("%s%s.%s.%s", subject, courseNumber, sectionNumber, term))
+}
+
+// Links cross-listed sections to each other, using both the pages' cross-list fields and sections of different courses that share a meeting signature
+func (p *Parser) linkCrossLists() {
+	links := make(map[primitive.ObjectID]map[primitive.ObjectID]bool)
+	addLink := func(section1 primitive.ObjectID, section2 primitive.ObjectID) {
+		for _, pair := range [][2]primitive.ObjectID{{section1, section2}, {section2, section1}} {
+			if links[pair[0]] == nil {
+				links[pair[0]] = make(map[primitive.ObjectID]bool)
+			}
+			links[pair[0]][pair[1]] = true
+		}
+	}
+
+	// Index sections by key and by meeting signature
+	sectionsByKey := make(map[string]*schema.Section, len(p.sections))
+	sectionsBySignature := make(map[string][]*schema.Section)
+	for _, section := range p.sections {
+		course := p.courses[p.courseIDMap[section.Course_reference]]
+		sectionsByKey[sectionKey(course.Subject_prefix, course.Course_number, section.Section_number, section.Academic_session.Name)] = section
+		if signature, ok := meetingSignature(section); ok {
+			sectionsBySignature[signature] = append(sectionsBySignature[signature], section)
+		}
+	}
+
+	// Explicit cross-lists from the pages
+	for sectionId, keys := range p.crossListKeys {
+		// Sections may have been dropped since their cross-lists were read, i.e. when dropping cancelled sections
+		if _, exists := p.sections[sectionId]; !exists {
+			continue
+		}
+		for _, key := range keys {
+			crossListed, exists := sectionsByKey[key]
+			if !exists {
+				utils.VPrintf("Cross-listed section %s wasn't parsed, skipping it", key)
+				continue
+			}
+			if crossListed.Id != sectionId {
+				addLink(sectionId, crossListed.Id)
+			}
+		}
+	}
+
+	// Sections of different courses meeting at the same time and place with the same professors
+	for _, sections := range sectionsBySignature {
+		for i := 0; i < len(sections)-1; i++ {
+			for j := i + 1; j < len(sections); j++ {
+				if sections[i].Course_reference != sections[j].Course_reference {
+					addLink(sections[i].Id, sections[j].Id)
+				}
+			}
+		}
+	}
+
+	for sectionId, linkedIds := range links {
+		crossListed := utils.GetMapKeys(linkedIds)
+		sort.Slice(crossListed, func(i, j int) bool {
+			return crossListed[i].Hex() < crossListed[j].Hex()
+		})
+		getSectionAttributes(p.sections[sectionId]).CrossListed = crossListed
+	}
+	log.Printf("Linked %d cross-listed sections.", len(links))
+}
+
+// Makes a signature for a section out of its term, meetings, and professors.
+// Only sections with at least one meeting at a set time in a set room have a signature, since online and TBA sections would match each other by coincidence.
+func meetingSignature(section *schema.Section) (string, bool) {
+	located := false
+	meetingSignatures := make([]string, 0, len(section.Meetings))
+	for _, meeting := range section.Meetings {
+		if meeting.Start_time != "" && meeting.Location.Room != "" {
+			located = true
+		}
+		meetingSignatures = append(meetingSignatures, fmt.Sprintf("%s|%s|%s|%s-%s|%s %s",
+			meeting.Start_date.Format("2006-01-02"),
+			meeting.End_date.Format("2006-01-02"),
+			strings.Join(meeting.Meeting_days, ","),
+			meeting.Start_time,
+			meeting.End_time,
+			meeting.Location.Building,
+			meeting.Location.Room,
+		))
+	}
+	if !located {
+		return "", false
+	}
+	sort.Strings(meetingSignatures)
+
+	professors := make([]string, 0, len(section.Professors))
+	for _, profId := range section.Professors {
+		professors = append(professors, profId.Hex())
+	}
+	sort.Strings(professors)
+
+	return fmt.Sprintf("%s;%s;%s", section.Academic_session.Name, strings.Join(meetingSignatures, ";"), strings.Join(professors, ",")), true
+}
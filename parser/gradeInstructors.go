@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Creates sections from the grade data for terms that weren't parsed from coursebook, so grade history goes back further than the scraped data
+func WithGradeOnlySections(gradeOnlySections bool) Option {
+	return func(p *Parser) {
+		p.gradeOnlySections = gradeOnlySections
+	}
+}
+
+// Professors keyed by a loose form of their name, since grade data and coursebook don't always list names the same way
+type professorIndex map[string][]*schema.Professor
+
+func (p *Parser) newProfessorIndex() professorIndex {
+	index := make(professorIndex, len(p.professors))
+	for _, prof := range p.professors {
+		index.add(prof)
+	}
+	return index
+}
+
+func (index professorIndex) add(prof *schema.Professor) {
+	key := looseNameKey(prof.First_name, prof.Last_name)
+	index[key] = append(index[key], prof)
+}
+
+// Makes a key from the first word of the first name and the last word of the last name, so "Jason W Smith" and "Jason Smith" match,
+// as do coursebook's "Jan Van Der Berg" (whose last name is read as "Berg") and the grade data's "Van Der Berg, Jan"
+func looseNameKey(firstName string, lastName string) string {
+	firstNames := strings.Fields(strings.ToLower(firstName))
+	lastNames := strings.Fields(strings.ToLower(lastName))
+	if len(firstNames) == 0 || len(lastNames) == 0 {
+		return ""
+	}
+	return firstNames[0] + " " + lastNames[len(lastNames)-1]
+}
+
+// Splits an instructor's name as the grade data lists it, i.e. "Smith, Jason W", into first and last names
+func splitGradeInstructor(name string) (string, string) {
+	if lastName, firstName, hasComma := strings.Cut(name, ","); hasComma {
+		return utils.TrimWhitespace(firstName), utils.TrimWhitespace(lastName)
+	}
+	// Fall back to splitting the name like coursebook names are split
+	names := strings.Fields(name)
+	return strings.Join(names[:len(names)-1], " "), names[len(names)-1]
+}
+
+// Finds the professor an instructor listed in the grade data refers to, or nil if there's no professor by that name or it could be more than one
+func (index professorIndex) find(name string) *schema.Professor {
+	firstName, lastName := splitGradeInstructor(name)
+	candidates := index[looseNameKey(firstName, lastName)]
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	// Only settle an ambiguous name with an exact match
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate.First_name, firstName) && strings.EqualFold(candidate.Last_name, lastName) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// Finds the professor an instructor listed in the grade data refers to, creating one if there's none
+func (p *Parser) gradeInstructor(index professorIndex, name string) *schema.Professor {
+	if prof := index.find(name); prof != nil {
+		return prof
+	}
+	firstName, lastName := splitGradeInstructor(name)
+	profKey := firstName + lastName
+	if prof, exists := p.professors[profKey]; exists {
+		return prof
+	}
+	prof := &schema.Professor{
+		Id:         professorID(firstName, lastName),
+		First_name: firstName,
+		Last_name:  lastName,
+		Titles:     []string{},
+		Sections:   []primitive.ObjectID{},
+	}
+	p.professors[profKey] = prof
+	p.professorIDMap[prof.Id] = profKey
+	index.add(prof)
+	return prof
+}
+
+// Gets the parsed sections ordered by ID, so professors are created and referenced in the same order from run to run
+func (p *Parser) sortedSections() []*schema.Section {
+	sections := utils.GetMapValues(p.sections)
+	sort.Slice(sections, func(i, j int) bool {
+		return sections[i].Id.Hex() < sections[j].Id.Hex()
+	})
+	return sections
+}
+
+// Checks each section's instructors in the grade data against its instructors in coursebook, reporting any that don't match.
+// Sections coursebook lists no instructors for are given the grade data's instructors.
+func (p *Parser) attributeGradeInstructors() {
+	index := p.newProfessorIndex()
+	attributed := 0
+	for _, section := range p.sortedSections() {
+		course, exists := p.courses[p.courseIDMap[section.Course_reference]]
+		if !exists {
+			continue
+		}
+		record, exists := p.gradeMap[section.Academic_session.Name][gradeKey(course, section.Section_number)]
+		if !exists || len(record.instructors) == 0 {
+			continue
+		}
+
+		if len(section.Professors) == 0 {
+			for _, name := range record.instructors {
+				prof := p.gradeInstructor(index, name)
+				section.Professors = append(section.Professors, prof.Id)
+				prof.Sections = append(prof.Sections, section.Id)
+			}
+			attributed++
+			continue
+		}
+
+		if !p.instructorsMatch(index, record.instructors, section.Professors) {
+			coursebookInstructors := make([]string, 0, len(section.Professors))
+			for _, profId := range section.Professors {
+				prof := p.professors[p.professorIDMap[profId]]
+				coursebookInstructors = append(coursebookInstructors, prof.First_name+" "+prof.Last_name)
+			}
+			p.report.InstructorMismatches = append(p.report.InstructorMismatches, InstructorMismatch{
+				Section:               sectionKey(course.Subject_prefix, course.Course_number, section.Section_number, section.Academic_session.Name),
+				GradeInstructors:      record.instructors,
+				CoursebookInstructors: coursebookInstructors,
+			})
+		}
+	}
+	if attributed > 0 {
+		log.Printf("Took the instructors of %d sections from grade data, since coursebook didn't list any.", attributed)
+	}
+	if len(p.report.InstructorMismatches) > 0 {
+		log.Printf("WARN: %d sections have different instructors in grade data than in coursebook.", len(p.report.InstructorMismatches))
+	}
+}
+
+// Reports whether the instructors listed in the grade data are exactly the given professors
+func (p *Parser) instructorsMatch(index professorIndex, instructors []string, profIds []primitive.ObjectID) bool {
+	matched := make(map[primitive.ObjectID]bool, len(profIds))
+	for _, name := range instructors {
+		prof := index.find(name)
+		if prof == nil {
+			return false
+		}
+		matched[prof.Id] = true
+	}
+	for _, profId := range profIds {
+		if !matched[profId] {
+			return false
+		}
+	}
+	return len(matched) == len(profIds)
+}
+
+// Adds a section for every row of grade data in terms that weren't parsed from coursebook, under the version of its course
+// from the nearest catalog year. Rows for courses that were never parsed are counted in the report.
+func (p *Parser) addGradeOnlySections() {
+	parsedTerms := make(map[string]bool)
+	for _, section := range p.sections {
+		parsedTerms[section.Academic_session.Name] = true
+	}
+	coursesByNumber := make(map[string][]*schema.Course)
+	for _, course := range p.courses {
+		number := strings.ToUpper(course.Subject_prefix + course.Course_number)
+		coursesByNumber[number] = append(coursesByNumber[number], course)
+	}
+	index := p.newProfessorIndex()
+
+	terms := utils.GetMapKeys(p.gradeMap)
+	sort.Strings(terms)
+	for _, term := range terms {
+		if parsedTerms[term] || !termDirRegexp.MatchString(term) || (len(p.terms) != 0 && !p.terms[term]) {
+			continue
+		}
+		session := schema.AcademicSession{Name: term}
+		catalogYear, _ := strconv.Atoi(getCatalogYear(session))
+
+		gradeKeys := utils.GetMapKeys(p.gradeMap[term])
+		sort.Strings(gradeKeys)
+		for _, key := range gradeKeys {
+			record := p.gradeMap[term][key]
+			course := nearestCourse(coursesByNumber[strings.ToUpper(record.subject+record.catalogNumber)], catalogYear)
+			if course == nil {
+				p.report.GradeRowsWithoutCourse++
+				continue
+			}
+
+			section := &schema.Section{
+				Id:                  utils.DeterministicID("section", record.sectionNumber, course.Id.Hex(), term),
+				Section_number:      record.sectionNumber,
+				Course_reference:    course.Id,
+				Academic_session:    session,
+				Professors:          make([]primitive.ObjectID, 0, len(record.instructors)),
+				Teaching_assistants: []schema.Assistant{},
+				Meetings:            []schema.Meeting{},
+			}
+			if _, exists := p.sections[section.Id]; exists {
+				continue
+			}
+			p.attachGrades(section, record.key(term), record.distribution)
+			getSectionAttributes(section).GradeOnly = true
+			for _, name := range record.instructors {
+				prof := p.gradeInstructor(index, name)
+				section.Professors = append(section.Professors, prof.Id)
+				prof.Sections = append(prof.Sections, section.Id)
+			}
+
+			record.matched = true
+			p.sections[section.Id] = section
+			course.Sections = append(course.Sections, section.Id)
+			p.report.GradeOnlySections++
+		}
+	}
+	log.Printf("Created %d sections from grade data alone. %d grade rows were for courses that were never parsed.", p.report.GradeOnlySections, p.report.GradeRowsWithoutCourse)
+}
+
+// Gets the version of a course from the catalog year nearest to the given one, preferring the earlier year on ties
+func nearestCourse(versions []*schema.Course, catalogYear int) *schema.Course {
+	var nearest *schema.Course
+	nearestDistance := 0
+	for _, course := range versions {
+		courseYear, err := strconv.Atoi(course.Catalog_year)
+		if err != nil {
+			continue
+		}
+		distance := courseYear - catalogYear
+		if distance < 0 {
+			distance = -distance
+		}
+		if nearest == nil || distance < nearestDistance || (distance == nearestDistance && course.Catalog_year < nearest.Catalog_year) {
+			nearest = course
+			nearestDistance = distance
+		}
+	}
+	return nearest
+}
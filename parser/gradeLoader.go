@@ -6,18 +6,145 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
 )
 
-func loadGrades(csvDir string) map[string]map[string][]int {
+// Number of students who got each grade in a section, stored in the section's Attributes
+type GradeDistribution struct {
+	A_plus  int `bson:"A+" json:"A+"`
+	A       int `bson:"A" json:"A"`
+	A_minus int `bson:"A-" json:"A-"`
+	B_plus  int `bson:"B+" json:"B+"`
+	B       int `bson:"B" json:"B"`
+	B_minus int `bson:"B-" json:"B-"`
+	C_plus  int `bson:"C+" json:"C+"`
+	C       int `bson:"C" json:"C"`
+	C_minus int `bson:"C-" json:"C-"`
+	D_plus  int `bson:"D+" json:"D+"`
+	D       int `bson:"D" json:"D"`
+	D_minus int `bson:"D-" json:"D-"`
+	F       int `bson:"F" json:"F"`
+	W       int `bson:"W" json:"W"`
+	// The grades besides letter grades and withdrawals, which not every term's grade data has columns for
+	CR int `bson:"CR" json:"CR"`
+	NC int `bson:"NC" json:"NC"`
+	P  int `bson:"P" json:"P"`
+	NF int `bson:"NF" json:"NF"`
+	I  int `bson:"I" json:"I"`
+}
+
+// Grades in the order they appear in a section's Grade_distribution
+var legacyGrades = []string{"A+", "A", "A-", "B+", "B", "B-", "C+", "C", "C-", "D+", "D", "D-", "F", "W"}
 
-	// MAP[SEMESTER] -> MAP[SUBJECT + NUMBER + SECTION] -> GRADE DISTRIBUTION
-	gradeMap := make(map[string]map[string][]int)
+// Every grade in a GradeDistribution
+var allGrades = append(append([]string{}, legacyGrades...), "CR", "NC", "P", "NF", "I")
+
+var instructorColRegexp = regexp.MustCompile(`^Instructor \d+$`)
+
+// Gets the count for the grade as it's labeled in the grade data, or nil if there's no such grade
+func (distribution *GradeDistribution) count(grade string) *int {
+	switch grade {
+	case "A+":
+		return &distribution.A_plus
+	case "A":
+		return &distribution.A
+	case "A-":
+		return &distribution.A_minus
+	case "B+":
+		return &distribution.B_plus
+	case "B":
+		return &distribution.B
+	case "B-":
+		return &distribution.B_minus
+	case "C+":
+		return &distribution.C_plus
+	case "C":
+		return &distribution.C
+	case "C-":
+		return &distribution.C_minus
+	case "D+":
+		return &distribution.D_plus
+	case "D":
+		return &distribution.D
+	case "D-":
+		return &distribution.D_minus
+	case "F":
+		return &distribution.F
+	case "W":
+		return &distribution.W
+	case "CR":
+		return &distribution.CR
+	case "NC":
+		return &distribution.NC
+	case "P":
+		return &distribution.P
+	case "NF":
+		return &distribution.NF
+	case "I":
+		return &distribution.I
+	default:
+		return nil
+	}
+}
+
+// Gets the letter grade and withdrawal counts in the order schema.Section's Grade_distribution has always used
+func (distribution *GradeDistribution) Legacy() []int {
+	legacy := make([]int, len(legacyGrades))
+	for i, grade := range legacyGrades {
+		legacy[i] = *distribution.count(grade)
+	}
+	return legacy
+}
+
+// Adds the counts of another distribution to this one
+func (distribution *GradeDistribution) add(other *GradeDistribution) {
+	for _, grade := range allGrades {
+		*distribution.count(grade) += *other.count(grade)
+	}
+}
+
+// Gets the total count of the given grades
+func (distribution *GradeDistribution) sum(grades []string) int {
+	total := 0
+	for _, grade := range grades {
+		total += *distribution.count(grade)
+	}
+	return total
+}
+
+// Gets the number of students with any grade
+func (distribution *GradeDistribution) total() int {
+	return distribution.sum(allGrades)
+}
+
+// A single section's row of grade data
+type gradeRecord struct {
+	subject       string
+	catalogNumber string
+	sectionNumber string
+	// Instructors as they're listed in the grade data, i.e. "Smith, Jason W"
+	instructors  []string
+	distribution *GradeDistribution
+	// Whether a section was given this record's grades
+	matched bool
+}
+
+// Loads the grade data from every CSV in csvDir, along with any rows in each term's CSV that repeat an earlier row's key
+func loadGrades(csvDir string) (map[string]map[string]*gradeRecord, map[string][]*gradeRecord) {
+
+	// MAP[SEMESTER] -> MAP[SUBJECT + NUMBER + SECTION] -> GRADE RECORD
+	gradeMap := make(map[string]map[string]*gradeRecord)
+	duplicates := make(map[string][]*gradeRecord)
 
 	if csvDir == "" {
 		log.Print("No grade data CSV directory specified. Grade data will not be included.")
-		return gradeMap
+		return gradeMap, duplicates
 	}
 
 	dirPtr, err := os.Open(csvDir)
@@ -46,8 +173,8 @@ func loadGrades(csvDir string) map[string]map[string][]int {
 		defer csvFile.Close()
 
 		// Create logs directory
-		if _, err := os.Stat("./logs/grades"); err != nil {
-			os.Mkdir("./logs/grades", os.ModePerm)
+		if err := os.MkdirAll("./logs/grades", os.ModePerm); err != nil {
+			panic(err)
 		}
 
 		// Create log file [name of csv].log in logs directory
@@ -61,51 +188,47 @@ func loadGrades(csvDir string) map[string]map[string][]int {
 		defer logFile.Close()
 
 		// Put data from csv into map
-		gradeMap[csvName] = csvToMap(csvFile, logFile)
+		gradeMap[csvName], duplicates[csvName] = csvToMap(csvFile, logFile)
 	}
 
-	return gradeMap
+	return gradeMap, duplicates
 }
 
-func csvToMap(csvFile *os.File, logFile *os.File) map[string][]int {
+func csvToMap(csvFile *os.File, logFile *os.File) (map[string]*gradeRecord, []*gradeRecord) {
 	reader := csv.NewReader(csvFile)
 	records, err := reader.ReadAll() // records is [][]strings
 	if err != nil {
 		log.Panicf("Error parsing %s: %s", csvFile.Name(), err.Error())
 	}
-	// look for the subject column and w column
+	// look for the subject, catalog number, and section columns, along with every grade and instructor column
 	subjectCol := -1
 	catalogNumberCol := -1
 	sectionCol := -1
-	wCol := -1
-	aPlusCol := -1
+	gradeCols := make(map[int]string)
+	var instructorCols []int
 
 	headerRow := records[0]
 
 	for j := 0; j < len(headerRow); j++ {
+		header := strings.TrimSpace(headerRow[j])
 		switch {
-		case headerRow[j] == "Subject":
+		case header == "Subject":
 			subjectCol = j
-		case headerRow[j] == "Catalog Number" || headerRow[j] == "Catalog Nbr":
+		case header == "Catalog Number" || header == "Catalog Nbr":
 			catalogNumberCol = j
-		case headerRow[j] == "Section":
+		case header == "Section":
 			sectionCol = j
-		case headerRow[j] == "W" || headerRow[j] == "Total W" || headerRow[j] == "W Total":
-			wCol = j
-		case headerRow[j] == "A+":
-			aPlusCol = j
-		}
-		if wCol == -1 || subjectCol == -1 || catalogNumberCol == -1 || sectionCol == -1 || aPlusCol == -1 {
-			continue
-		} else {
-			break
+		case header == "Total W" || header == "W Total":
+			gradeCols[j] = "W"
+		case new(GradeDistribution).count(header) != nil:
+			gradeCols[j] = header
+		case instructorColRegexp.MatchString(header):
+			instructorCols = append(instructorCols, j)
+		default:
+			logFile.WriteString(fmt.Sprintf("ignoring unknown column %s\n", header))
 		}
 	}
 
-	if wCol == -1 {
-		logFile.WriteString("could not find W column")
-		//log.Panicf("could not find W column")
-	}
 	if sectionCol == -1 {
 		logFile.WriteString("could not find Section column")
 		log.Panicf("could not find Section column")
@@ -118,30 +241,56 @@ func csvToMap(csvFile *os.File, logFile *os.File) map[string][]int {
 		logFile.WriteString("could not find catalog # column")
 		log.Panicf("could not find catalog # column")
 	}
-	if aPlusCol == -1 {
-		logFile.WriteString("could not find A+ column")
-		log.Panicf("could not find A+ column")
+	// Every file should at least have letter grades and withdrawals, so note any that are missing
+	for _, grade := range legacyGrades {
+		if !slices.Contains(utils.GetMapValues(gradeCols), grade) {
+			logFile.WriteString(fmt.Sprintf("could not find %s column\n", grade))
+		}
 	}
 
-	distroMap := make(map[string][]int)
+	distroMap := make(map[string]*gradeRecord)
+	var duplicates []*gradeRecord
 
-	for _, record := range records {
-		// convert grade distribution from string to int
-		intSlice := [14]int{}
-
-		for j := 0; j < 13; j++ {
-			intSlice[j], _ = strconv.Atoi(record[aPlusCol+j])
+	// Skip the header row, which isn't a section
+	for _, record := range records[1:] {
+		// convert grade distribution from string to int; empty cells mean no students got that grade
+		distribution := &GradeDistribution{}
+		for j, grade := range gradeCols {
+			*distribution.count(grade), _ = strconv.Atoi(strings.TrimSpace(record[j]))
 		}
-		// add w number to the grade_distribution slice
-		if wCol != -1 {
-			intSlice[13], _ = strconv.Atoi(record[wCol])
+
+		var instructors []string
+		for _, j := range instructorCols {
+			if instructor := utils.TrimWhitespace(record[j]); instructor != "" {
+				instructors = append(instructors, instructor)
+			}
 		}
 
-		// add new grade distribution to map, keyed by SUBJECT + NUMBER + SECTION
+		// add new grade record to map, keyed by SUBJECT + NUMBER + SECTION
 		// Be sure to trim left padding on section number
 		trimmedSectionNumber := strings.TrimLeft(record[sectionCol], "0")
 		distroKey := record[subjectCol] + record[catalogNumberCol] + trimmedSectionNumber
-		distroMap[distroKey] = intSlice[:]
+		gradeRecord := &gradeRecord{
+			subject:       record[subjectCol],
+			catalogNumber: record[catalogNumberCol],
+			sectionNumber: record[sectionCol],
+			instructors:   instructors,
+			distribution:  distribution,
+		}
+		// Later rows win, but note the row being replaced
+		if duplicate, exists := distroMap[distroKey]; exists {
+			logFile.WriteString(fmt.Sprintf("duplicate row for %s\n", distroKey))
+			duplicates = append(duplicates, duplicate)
+		}
+		distroMap[distroKey] = gradeRecord
 	}
-	return distroMap
+	return distroMap, duplicates
+}
+
+// Gets the key of a section's grade record within its term's grade data
+func gradeKey(course *schema.Course, sectionNumber string) string {
+	// We have to trim leading zeroes from the section number in order to match properly, since the grade data does not use leading zeroes
+	trimmedSectionNumber := strings.TrimLeft(sectionNumber, "0")
+	// Key into grademap should be uppercased like the grade data
+	return strings.ToUpper(course.Subject_prefix + course.Course_number + trimmedSectionNumber)
 }
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// What's done with a grade distribution that has fewer students than the minimum cohort size
+const (
+	// The distribution is left out entirely
+	SUPPRESS_DROP = "suppress"
+	// Plus and minus letter grades are folded into their base letter grade
+	SUPPRESS_COARSEN = "coarsen"
+)
+
+// What was done to grades that were held back, as recorded in the grade report and the section's attributes
+const (
+	SUPPRESSED = "suppressed"
+	COARSENED  = "coarsened"
+)
+
+// Fewest students a grade distribution may have to be coarsened rather than left out, since coarsening a cohort of one or two
+// students still shows their grades
+const MIN_COARSENED_COHORT = 3
+
+// Checks that the mode is one of the suppression modes
+func ValidateSuppressionMode(mode string) error {
+	if mode != SUPPRESS_DROP && mode != SUPPRESS_COARSEN {
+		return fmt.Errorf("unknown suppression mode '%s', expected '%s' or '%s'", mode, SUPPRESS_DROP, SUPPRESS_COARSEN)
+	}
+	return nil
+}
+
+// Holds back grade distributions with fewer than minCohort students, which could identify individual students, using the given suppression mode.
+// Distributions with fewer than MIN_COARSENED_COHORT students are always left out, even when coarsening.
+// Grade statistics are computed from the grades as they're published, and those with fewer students are always left out. Nothing is held back if minCohort is 0, which is the default.
+func WithMinCohort(minCohort int, mode string) Option {
+	return func(p *Parser) {
+		p.minCohort = minCohort
+		p.suppressionMode = mode
+	}
+}
+
+// A grade distribution or grade statistics that were held back for having too few students
+type SuppressedGrades struct {
+	// Section key i.e. "CS1337.001.23F", or the key of the grade statistics
+	Key      string `json:"key"`
+	Kind     string `json:"kind"`
+	Students int    `json:"students"`
+	// Either SUPPRESSED or COARSENED
+	Action string `json:"action"`
+}
+
+// Gives a section its grades, unless it has too few students to publish them as they are
+func (p *Parser) attachGrades(section *schema.Section, key string, distribution *GradeDistribution) {
+	students := distribution.total()
+	if students < p.minCohort {
+		if p.suppressionMode != SUPPRESS_COARSEN || students < MIN_COARSENED_COHORT {
+			getSectionAttributes(section).GradeSuppression = SUPPRESSED
+			p.suppressedGrades = append(p.suppressedGrades, SuppressedGrades{key, STATS_SECTION, students, SUPPRESSED})
+			return
+		}
+		distribution = distribution.coarsened()
+		getSectionAttributes(section).GradeSuppression = COARSENED
+		p.suppressedGrades = append(p.suppressedGrades, SuppressedGrades{key, STATS_SECTION, students, COARSENED})
+	}
+	section.Grade_distribution = distribution.Legacy()
+	getSectionAttributes(section).Grades = distribution
+}
+
+// Reports whether the section's grades were held back entirely
+func gradesSuppressed(section *schema.Section) bool {
+	attributes, ok := section.Attributes.(*SectionAttributes)
+	return ok && attributes.GradeSuppression == SUPPRESSED
+}
+
+// Leaves out grade statistics with too few students
+func (p *Parser) suppressGradeStats(stats []*GradeStats) []*GradeStats {
+	// Sections whose grades were coarsened are already reported, so their statistics aren't reported again
+	coarsened := make(map[string]bool)
+	for _, suppressed := range p.suppressedGrades {
+		if suppressed.Kind == STATS_SECTION && suppressed.Action == COARSENED {
+			coarsened[suppressed.Key] = true
+		}
+	}
+	kept := make([]*GradeStats, 0, len(stats))
+	for _, stat := range stats {
+		if stat.Students < p.minCohort {
+			if stat.Kind != STATS_SECTION || !coarsened[stat.Key] {
+				p.suppressedGrades = append(p.suppressedGrades, SuppressedGrades{stat.Key, stat.Kind, stat.Students, SUPPRESSED})
+			}
+			continue
+		}
+		kept = append(kept, stat)
+	}
+	return kept
+}
+
+// Gets a copy of the distribution with plus and minus letter grades folded into their base letter grade, i.e. A+ and A- into A
+func (distribution *GradeDistribution) coarsened() *GradeDistribution {
+	coarse := *distribution
+	coarse.A += coarse.A_plus + coarse.A_minus
+	coarse.B += coarse.B_plus + coarse.B_minus
+	coarse.C += coarse.C_plus + coarse.C_minus
+	coarse.D += coarse.D_plus + coarse.D_minus
+	coarse.A_plus, coarse.A_minus = 0, 0
+	coarse.B_plus, coarse.B_minus = 0, 0
+	coarse.C_plus, coarse.C_minus = 0, 0
+	coarse.D_plus, coarse.D_minus = 0, 0
+	return &coarse
+}
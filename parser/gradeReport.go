@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"log"
+	"sort"
+
+	"github.com/UTDNebula/api-tools/utils"
+)
+
+// How well a term's grade data lined up with the term's sections
+type TermGradeReport struct {
+	Term        string `json:"term"`
+	GradeRows   int    `json:"grade_rows"`
+	MatchedRows int    `json:"matched_rows"`
+	// Fraction of the term's grade rows that were matched to a section
+	MatchRate float64 `json:"match_rate"`
+	Sections  int     `json:"sections"`
+	// Grade rows that weren't matched to any section, and sections that weren't matched to any grade row, by section key i.e. "CS1337.001.23F"
+	UnmatchedRows         []string `json:"unmatched_rows"`
+	SectionsWithoutGrades []string `json:"sections_without_grades"`
+	// Rows replaced by a later row with the same key in the term's CSV
+	DuplicateRows []string `json:"duplicate_rows"`
+}
+
+// Summary of how the grade data lined up with the parsed sections, by term
+type GradeReport struct {
+	Terms []TermGradeReport `json:"terms"`
+	// Terms there's grade data for, but no sections were parsed for
+	UnparsedTerms []string `json:"unparsed_terms"`
+	// Grades held back for having fewer students than MinCohort, which is 0 when nothing is held back
+	MinCohort  int                `json:"min_cohort"`
+	Suppressed []SuppressedGrades `json:"suppressed"`
+}
+
+// Reconciles the grade data with the parsed sections; must be run after every section has been given its grades.
+// Cancelled sections never get grades, so they aren't listed as sections without grades, and neither are sections whose grades were suppressed.
+func (p *Parser) reconcileGrades() *GradeReport {
+	report := &GradeReport{Terms: []TermGradeReport{}, UnparsedTerms: []string{}, MinCohort: p.minCohort, Suppressed: p.suppressedGrades}
+
+	sectionCounts := make(map[string]int)
+	sectionsWithoutGrades := make(map[string][]string)
+	for _, section := range p.sections {
+		term := section.Academic_session.Name
+		if _, hasGrades := p.gradeMap[term]; !hasGrades || isCancelled(section) {
+			continue
+		}
+		sectionCounts[term]++
+		if section.Grade_distribution != nil || gradesSuppressed(section) {
+			continue
+		}
+		if course, exists := p.courses[p.courseIDMap[section.Course_reference]]; exists {
+			key := sectionKey(course.Subject_prefix, course.Course_number, section.Section_number, term)
+			sectionsWithoutGrades[term] = append(sectionsWithoutGrades[term], key)
+		}
+	}
+
+	terms := utils.GetMapKeys(p.gradeMap)
+	sort.Strings(terms)
+	for _, term := range terms {
+		sections, parsed := sectionCounts[term]
+		if !parsed {
+			report.UnparsedTerms = append(report.UnparsedTerms, term)
+			continue
+		}
+
+		termReport := TermGradeReport{
+			Term:                  term,
+			GradeRows:             len(p.gradeMap[term]),
+			Sections:              sections,
+			UnmatchedRows:         []string{},
+			SectionsWithoutGrades: append([]string{}, sectionsWithoutGrades[term]...),
+			DuplicateRows:         []string{},
+		}
+		for _, record := range p.gradeMap[term] {
+			if record.matched {
+				termReport.MatchedRows++
+			} else {
+				termReport.UnmatchedRows = append(termReport.UnmatchedRows, record.key(term))
+			}
+		}
+		for _, record := range p.gradeDuplicates[term] {
+			termReport.DuplicateRows = append(termReport.DuplicateRows, record.key(term))
+		}
+		if termReport.GradeRows > 0 {
+			termReport.MatchRate = float64(termReport.MatchedRows) / float64(termReport.GradeRows)
+		}
+		sort.Strings(termReport.UnmatchedRows)
+		sort.Strings(termReport.SectionsWithoutGrades)
+		sort.Strings(termReport.DuplicateRows)
+
+		log.Printf("Grade data for %s: matched %d of %d rows (%.1f%%), with %d unmatched rows, %d of %d sections without grades, and %d duplicate rows.",
+			term, termReport.MatchedRows, termReport.GradeRows, termReport.MatchRate*100, len(termReport.UnmatchedRows),
+			len(termReport.SectionsWithoutGrades), termReport.Sections, len(termReport.DuplicateRows))
+		report.Terms = append(report.Terms, termReport)
+	}
+	if len(report.Suppressed) > 0 {
+		log.Printf("Held back %d grade distributions and statistics with fewer than %d students; see the grade report for which.", len(report.Suppressed), p.minCohort)
+	}
+	return report
+}
+
+// Gets the key of the section the record is for, in the same form as the parser's other section keys
+func (record *gradeRecord) key(term string) string {
+	return sectionKey(record.subject, record.catalogNumber, record.sectionNumber, term)
+}
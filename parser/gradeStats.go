@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// What grade statistics are for
+const (
+	STATS_SECTION   = "section"
+	STATS_COURSE    = "course"
+	STATS_PROFESSOR = "professor"
+)
+
+// GPA points for each letter grade, as UTD weighs them; grades without a weight don't count toward GPA
+var DefaultGPAWeights = map[string]float64{
+	"A+": 4.0, "A": 4.0, "A-": 3.67,
+	"B+": 3.33, "B": 3.0, "B-": 2.67,
+	"C+": 2.33, "C": 2.0, "C-": 1.67,
+	"D+": 1.33, "D": 1.0, "D-": 0.67,
+	"F": 0.0,
+}
+
+// Grades that pass and fail a course, for pass rates; withdrawals and incompletes are neither
+var passingGrades = []string{"A+", "A", "A-", "B+", "B", "B-", "C+", "C", "C-", "D+", "D", "D-", "CR", "P"}
+var failingGrades = []string{"F", "NC", "NF"}
+
+// Statistics derived from the grade distributions of a section, of every section of a course across terms, or of every section a professor taught
+type GradeStats struct {
+	Id   primitive.ObjectID `bson:"_id" json:"_id"`
+	Kind string             `bson:"kind" json:"kind"`
+	// The section's key i.e. "CS1337.001.23F", the course's subject and number i.e. "CS1337", or the professor's name
+	Key string `bson:"key" json:"key"`
+	// The section, every catalog year's version of the course, or the professor
+	References []primitive.ObjectID `bson:"references" json:"references"`
+	Sections   int                  `bson:"sections" json:"sections"`
+	Terms      []string             `bson:"terms" json:"terms"`
+	Students   int                  `bson:"students" json:"students"`
+	Grades     GradeDistribution    `bson:"grades" json:"grades"`
+	// These are null when no students got a grade they apply to
+	Mean_gpa               *float64 `bson:"mean_gpa" json:"mean_gpa"`
+	Gpa_standard_deviation *float64 `bson:"gpa_standard_deviation" json:"gpa_standard_deviation"`
+	Median_grade           string   `bson:"median_grade" json:"median_grade"`
+	Pass_rate              *float64 `bson:"pass_rate" json:"pass_rate"`
+	Withdrawal_rate        *float64 `bson:"withdrawal_rate" json:"withdrawal_rate"`
+}
+
+// Sets the GPA points for each grade used in grade statistics, which are DefaultGPAWeights by default
+func WithGPAWeights(weights map[string]float64) Option {
+	return func(p *Parser) {
+		p.gpaWeights = weights
+	}
+}
+
+// Reads GPA weights from a JSON file mapping grades to points, i.e. {"A": 4.0, "A-": 3.67, ...}
+func ReadGPAWeights(path string) (map[string]float64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var weights map[string]float64
+	if err := json.Unmarshal(content, &weights); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for grade := range weights {
+		if new(GradeDistribution).count(grade) == nil {
+			return nil, fmt.Errorf("%s: unknown grade '%s', expected one of %s", path, grade, strings.Join(allGrades, ", "))
+		}
+	}
+	return weights, nil
+}
+
+// Grade statistics being totaled up over several sections
+type gradeTotals struct {
+	references map[primitive.ObjectID]bool
+	sections   int
+	terms      map[string]bool
+	grades     GradeDistribution
+}
+
+func (totals *gradeTotals) add(reference primitive.ObjectID, section *schema.Section, grades *GradeDistribution) {
+	if totals.references == nil {
+		totals.references = make(map[primitive.ObjectID]bool)
+		totals.terms = make(map[string]bool)
+	}
+	totals.references[reference] = true
+	totals.sections++
+	totals.terms[section.Academic_session.Name] = true
+	totals.grades.add(grades)
+}
+
+// Computes grade statistics for every section with grades, then for each course and professor across all of their sections.
+// When only some terms are parsed, course and professor statistics would only cover those terms, so only section statistics are computed.
+func (p *Parser) computeGradeStats() []*GradeStats {
+	var stats []*GradeStats
+	courseTotals := make(map[string]*gradeTotals)
+	professorTotals := make(map[primitive.ObjectID]*gradeTotals)
+
+	for _, section := range p.sortedSections() {
+		attributes, ok := section.Attributes.(*SectionAttributes)
+		if !ok || attributes.Grades == nil {
+			continue
+		}
+		course, exists := p.courses[p.courseIDMap[section.Course_reference]]
+		if !exists {
+			continue
+		}
+
+		var sectionTotals gradeTotals
+		sectionTotals.add(section.Id, section, attributes.Grades)
+		key := sectionKey(course.Subject_prefix, course.Course_number, section.Section_number, section.Academic_session.Name)
+		stats = append(stats, p.newGradeStats(STATS_SECTION, key, &sectionTotals))
+
+		courseKey := strings.ToUpper(course.Subject_prefix + course.Course_number)
+		if courseTotals[courseKey] == nil {
+			courseTotals[courseKey] = &gradeTotals{}
+		}
+		courseTotals[courseKey].add(course.Id, section, attributes.Grades)
+
+		for _, profId := range section.Professors {
+			if professorTotals[profId] == nil {
+				professorTotals[profId] = &gradeTotals{}
+			}
+			professorTotals[profId].add(profId, section, attributes.Grades)
+		}
+	}
+
+	if len(p.terms) != 0 {
+		log.Print("Only some terms were parsed, so grade statistics for courses and professors were left out.")
+		return stats
+	}
+
+	courseKeys := utils.GetMapKeys(courseTotals)
+	sort.Strings(courseKeys)
+	for _, courseKey := range courseKeys {
+		stats = append(stats, p.newGradeStats(STATS_COURSE, courseKey, courseTotals[courseKey]))
+	}
+
+	var professorStats []*GradeStats
+	for profId, totals := range professorTotals {
+		prof, exists := p.professors[p.professorIDMap[profId]]
+		if !exists {
+			continue
+		}
+		professorStats = append(professorStats, p.newGradeStats(STATS_PROFESSOR, prof.First_name+" "+prof.Last_name, totals))
+	}
+	sort.Slice(professorStats, func(i, j int) bool {
+		return professorStats[i].Id.Hex() < professorStats[j].Id.Hex()
+	})
+	return append(stats, professorStats...)
+}
+
+func (p *Parser) newGradeStats(kind string, key string, totals *gradeTotals) *GradeStats {
+	stats := &GradeStats{
+		Id:         utils.DeterministicID("grade_stats", kind, key),
+		Kind:       kind,
+		Key:        key,
+		References: utils.GetMapKeys(totals.references),
+		Sections:   totals.sections,
+		Terms:      utils.GetMapKeys(totals.terms),
+		Students:   totals.grades.total(),
+		Grades:     totals.grades,
+	}
+	sort.Slice(stats.References, func(i, j int) bool {
+		return stats.References[i].Hex() < stats.References[j].Hex()
+	})
+	sort.Strings(stats.Terms)
+
+	grades := &totals.grades
+	// Sum in a fixed order so the results don't change from run to run
+	gpaStudents := 0
+	points := 0.0
+	for _, grade := range allGrades {
+		if weight, hasWeight := p.gpaWeights[grade]; hasWeight {
+			gpaStudents += *grades.count(grade)
+			points += float64(*grades.count(grade)) * weight
+		}
+	}
+	if gpaStudents > 0 {
+		mean := points / float64(gpaStudents)
+		variance := 0.0
+		for _, grade := range allGrades {
+			if weight, hasWeight := p.gpaWeights[grade]; hasWeight {
+				variance += float64(*grades.count(grade)) * (weight - mean) * (weight - mean)
+			}
+		}
+		standardDeviation := math.Sqrt(variance / float64(gpaStudents))
+		stats.Mean_gpa = &mean
+		stats.Gpa_standard_deviation = &standardDeviation
+		stats.Median_grade = p.medianGrade(grades, gpaStudents)
+	}
+
+	passing := grades.sum(passingGrades)
+	failing := grades.sum(failingGrades)
+	if passing+failing > 0 {
+		passRate := float64(passing) / float64(passing+failing)
+		stats.Pass_rate = &passRate
+	}
+	if stats.Students > 0 {
+		withdrawalRate := float64(grades.W) / float64(stats.Students)
+		stats.Withdrawal_rate = &withdrawalRate
+	}
+	return stats
+}
+
+// Gets the grade of the middle student among those with grades that count toward GPA, taking the lower grade when there are two
+func (p *Parser) medianGrade(grades *GradeDistribution, gpaStudents int) string {
+	// Order the weighted grades from lowest to highest, keeping the usual order of grades with equal weights
+	weighted := make([]string, 0, len(p.gpaWeights))
+	for i := len(allGrades) - 1; i >= 0; i-- {
+		if _, hasWeight := p.gpaWeights[allGrades[i]]; hasWeight {
+			weighted = append(weighted, allGrades[i])
+		}
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return p.gpaWeights[weighted[i]] < p.gpaWeights[weighted[j]]
+	})
+
+	seen := 0
+	for _, grade := range weighted {
+		seen += *grades.count(grade)
+		if seen*2 >= gpaStudents {
+			return grade
+		}
+	}
+	return ""
+}
@@ -0,0 +1,288 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// Meeting modalities
+const (
+	MODALITY_IN_PERSON  = "in-person"
+	MODALITY_ONLINE     = "online"
+	MODALITY_HYBRID     = "hybrid"
+	MODALITY_OFF_CAMPUS = "off-campus"
+)
+
+// Date range that starts each meeting in the schedule text, i.e. "August 21, 2023-December 15, 2023"
+var meetingDatesRegexp *regexp.Regexp = utils.Regexpf(`(%s)\s*-\s*(%s)`, utils.R_DATE_MDY, utils.R_DATE_MDY)
+
+// Meeting days at the start of a meeting, i.e. "Tuesday, Thursday"
+var meetingDaysRegexp *regexp.Regexp = utils.Regexpf(`^((?:%s(?:,\s*)?)+)`, utils.R_WEEKDAY)
+
+// Meeting times at the start of a meeting, i.e. "10:00am-11:15am"
+var meetingTimesRegexp *regexp.Regexp = utils.Regexpf(`^(%s)\s*-\s*(%s)`, utils.R_TIME_AM_PM, utils.R_TIME_AM_PM)
+
+// First day or time of a meeting, for schedules that don't list meeting dates
+var meetingStartRegexp *regexp.Regexp = utils.Regexpf(`%s|%s`, utils.R_WEEKDAY, utils.R_TIME_AM_PM)
+
+// Placeholder for days, times, or rooms that haven't been decided yet
+var meetingTBARegexp *regexp.Regexp = regexp.MustCompile(`^(?i)(?:TBA|TBD|To Be Announced)\b`)
+
+var onlineLocationRegexp *regexp.Regexp = regexp.MustCompile(`(?i)\b(?:online|internet|web|virtual|remote)\b`)
+var offCampusLocationRegexp *regexp.Regexp = regexp.MustCompile(`(?i)\boff[\s-]*campus\b`)
+
+// Separators between the rooms of a meeting held in several rooms, i.e. "ECSS 2.410, ECSS 2.412"
+var roomSeparatorRegexp *regexp.Regexp = regexp.MustCompile(`\s*(?:[,;/&]|\band\b)\s*`)
+var roomNumberRegexp *regexp.Regexp = regexp.MustCompile(`^\d+\.\d{3}[A-z]?$`)
+
+// Heading coursebook puts before the meetings in the schedule text, which is all there is when a section has no meetings
+var meetingsHeadingRegexp *regexp.Regexp = regexp.MustCompile(`(?i)^class\s+location\s+and\s+times\b`)
+
+// Punctuation left over around a meeting's pieces
+var meetingTrimRegexp *regexp.Regexp = regexp.MustCompile(`^[\s,;:\-]+|[\s,;:\-]+$`)
+
+// A piece of a section's schedule that couldn't be parsed
+type meetingError struct {
+	text string
+	err  error
+}
+
+// Parses all meetings out of a section's schedule text.
+// Meetings are never dropped; anything that can't be fully parsed is kept as best it can be and returned as an error alongside the meetings.
+func getMeetings(scheduleText string, session schema.AcademicSession, instructionMode string) ([]schema.Meeting, []meetingError) {
+	meetings := []schema.Meeting{}
+	var errs []meetingError
+
+	dateMatches := meetingDatesRegexp.FindAllStringSubmatchIndex(scheduleText, -1)
+	if len(dateMatches) == 0 {
+		// Some meetings only list days, times, and rooms, in which case they span the whole session
+		remainder := scheduleHeaderRemainder(scheduleText)
+		if remainder == "" {
+			return meetings, errs
+		}
+		meeting, chunkErrs := parseMeeting(remainder, session.Start_date, session.End_date, instructionMode)
+		if session.Start_date.IsZero() {
+			chunkErrs = append(chunkErrs, meetingError{remainder, fmt.Errorf("no meeting or session dates")})
+		}
+		return append(meetings, meeting...), append(errs, chunkErrs...)
+	}
+
+	for i, match := range dateMatches {
+		// Everything between this date range and the next belongs to this meeting
+		chunkEnd := len(scheduleText)
+		if i+1 < len(dateMatches) {
+			chunkEnd = dateMatches[i+1][0]
+		}
+		datesText := scheduleText[match[2]:match[3]]
+		startDate, err := time.ParseInLocation("January 2, 2006", utils.TrimWhitespace(datesText), timeLocation)
+		if err != nil {
+			errs = append(errs, meetingError{scheduleText[match[0]:chunkEnd], err})
+			continue
+		}
+		datesText = scheduleText[match[4]:match[5]]
+		endDate, err := time.ParseInLocation("January 2, 2006", utils.TrimWhitespace(datesText), timeLocation)
+		if err != nil {
+			errs = append(errs, meetingError{scheduleText[match[0]:chunkEnd], err})
+			continue
+		}
+
+		chunkMeetings, chunkErrs := parseMeeting(scheduleText[match[1]:chunkEnd], startDate, endDate, instructionMode)
+		meetings = append(meetings, chunkMeetings...)
+		errs = append(errs, chunkErrs...)
+	}
+	return meetings, errs
+}
+
+// Gets whatever follows the term, session dates, and meetings heading at the start of the schedule text
+func scheduleHeaderRemainder(scheduleText string) string {
+	headerEnd := -1
+	if dateIndices := datesRegexp.FindAllStringIndex(scheduleText, -1); dateIndices != nil {
+		headerEnd = dateIndices[len(dateIndices)-1][1]
+	} else if meetingStart := meetingStartRegexp.FindStringIndex(scheduleText); meetingStart != nil {
+		// Without session dates, the meeting starts at its first day or time
+		headerEnd = meetingStart[0]
+	}
+	if headerEnd == -1 {
+		return ""
+	}
+	remainder := meetingTrimRegexp.ReplaceAllString(scheduleText[headerEnd:], "")
+	return meetingTrimRegexp.ReplaceAllString(meetingsHeadingRegexp.ReplaceAllString(remainder, ""), "")
+}
+
+// Parses the days, times, and location(s) that follow a meeting's date range, returning one meeting per room
+func parseMeeting(text string, startDate time.Time, endDate time.Time, instructionMode string) ([]schema.Meeting, []meetingError) {
+	var errs []meetingError
+	meeting := schema.Meeting{
+		Start_date:   startDate,
+		End_date:     endDate,
+		Meeting_days: []string{},
+	}
+
+	remainder := meetingTrimRegexp.ReplaceAllString(text, "")
+	if tbaMatch := meetingTBARegexp.FindString(remainder); tbaMatch != "" {
+		// Days and times to be announced
+		remainder = meetingTrimRegexp.ReplaceAllString(remainder[len(tbaMatch):], "")
+	} else {
+		if daysMatch := meetingDaysRegexp.FindStringSubmatch(remainder); daysMatch != nil {
+			for _, day := range strings.Split(daysMatch[1], ",") {
+				if day = utils.TrimWhitespace(day); day != "" {
+					meeting.Meeting_days = append(meeting.Meeting_days, day)
+				}
+			}
+			remainder = meetingTrimRegexp.ReplaceAllString(remainder[len(daysMatch[0]):], "")
+		}
+		if timesMatch := meetingTimesRegexp.FindStringSubmatch(remainder); timesMatch != nil {
+			// Don't parse time into time object, adds unnecessary extra data
+			meeting.Start_time = timesMatch[1]
+			meeting.End_time = timesMatch[2]
+			remainder = meetingTrimRegexp.ReplaceAllString(remainder[len(timesMatch[0]):], "")
+		} else if tbaMatch := meetingTBARegexp.FindString(remainder); tbaMatch != "" {
+			remainder = meetingTrimRegexp.ReplaceAllString(remainder[len(tbaMatch):], "")
+		}
+	}
+
+	switch {
+	case remainder == "" || meetingTBARegexp.MatchString(remainder):
+		// No room (yet), so go by the section's instruction mode
+		meeting.Modality = modalityFromInstructionMode(instructionMode)
+		return []schema.Meeting{meeting}, errs
+	case onlineLocationRegexp.MatchString(remainder):
+		meeting.Modality = MODALITY_ONLINE
+		return []schema.Meeting{meeting}, errs
+	case offCampusLocationRegexp.MatchString(remainder):
+		meeting.Modality = MODALITY_OFF_CAMPUS
+		return []schema.Meeting{meeting}, errs
+	}
+
+	// Anything else is one or more rooms
+	meeting.Modality = MODALITY_IN_PERSON
+	if modalityFromInstructionMode(instructionMode) == MODALITY_HYBRID {
+		meeting.Modality = MODALITY_HYBRID
+	}
+	locations, unresolved := parseMeetingLocations(remainder)
+	if len(unresolved) > 0 {
+		errs = append(errs, meetingError{text, fmt.Errorf("couldn't resolve location '%s'", strings.Join(unresolved, "', '"))})
+	}
+	if len(locations) == 0 {
+		// Keep the meeting even though we don't know where it is
+		return []schema.Meeting{meeting}, errs
+	}
+
+	meetings := make([]schema.Meeting, 0, len(locations))
+	for _, location := range locations {
+		roomMeeting := meeting
+		roomMeeting.Meeting_days = append([]string{}, meeting.Meeting_days...)
+		roomMeeting.Location = location
+		meetings = append(meetings, roomMeeting)
+	}
+	return meetings, errs
+}
+
+// Parses the room(s) a meeting is held in, returning the locations along with any pieces that couldn't be resolved
+func parseMeetingLocations(text string) ([]schema.Location, []string) {
+	// A single location may itself contain separators, i.e. "Student Union, Galaxy Rooms"
+	if location, ok := utils.ParseLocation(text); ok && !roomSeparatorRegexp.MatchString(location.Room) {
+		if pieces := roomSeparatorRegexp.Split(text, -1); len(pieces) < 2 || !allRooms(pieces) {
+			return []schema.Location{location}, nil
+		}
+	}
+
+	var locations []schema.Location
+	var unresolved []string
+	building := ""
+	for _, piece := range roomSeparatorRegexp.Split(text, -1) {
+		piece = utils.TrimWhitespace(piece)
+		if piece == "" {
+			continue
+		}
+		// Rooms listed after the first may leave out the building, i.e. "ECSS 2.410, 2.412"
+		if roomNumberRegexp.MatchString(piece) && building != "" {
+			locations = append(locations, utils.NewLocation(building, piece))
+			continue
+		}
+		location, ok := utils.ParseLocation(piece)
+		if !ok {
+			unresolved = append(unresolved, piece)
+			continue
+		}
+		building = location.Building
+		locations = append(locations, location)
+	}
+	return locations, unresolved
+}
+
+// Reports whether every piece is a room, with or without its building
+func allRooms(pieces []string) bool {
+	for _, piece := range pieces {
+		piece = utils.TrimWhitespace(piece)
+		if !utils.IsRoomLocation(piece) && !roomNumberRegexp.MatchString(piece) {
+			return false
+		}
+	}
+	return true
+}
+
+var isoWeekdays = map[string]int{
+	"monday":    1,
+	"tuesday":   2,
+	"wednesday": 3,
+	"thursday":  4,
+	"friday":    5,
+	"saturday":  6,
+	"sunday":    7,
+}
+
+// Normalizes the days and times of each meeting, skipping meetings without set times
+func getMeetingTimes(meetings []schema.Meeting) []MeetingTime {
+	var meetingTimes []MeetingTime
+	for i, meeting := range meetings {
+		startMinutes, startErr := minutesSinceMidnight(meeting.Start_time)
+		endMinutes, endErr := minutesSinceMidnight(meeting.End_time)
+		if startErr != nil || endErr != nil {
+			continue
+		}
+		weekdays := make([]int, 0, len(meeting.Meeting_days))
+		for _, day := range meeting.Meeting_days {
+			if weekday, exists := isoWeekdays[strings.ToLower(day)]; exists {
+				weekdays = append(weekdays, weekday)
+			}
+		}
+		meetingTimes = append(meetingTimes, MeetingTime{
+			Meeting:          i,
+			Start_minutes:    startMinutes,
+			End_minutes:      endMinutes,
+			Duration_minutes: endMinutes - startMinutes,
+			Weekdays:         weekdays,
+		})
+	}
+	return meetingTimes
+}
+
+// Converts a coursebook time like "2:30pm" to minutes since midnight
+func minutesSinceMidnight(timeText string) (int, error) {
+	parsedTime, err := time.Parse("3:04pm", strings.ReplaceAll(strings.ToLower(timeText), " ", ""))
+	if err != nil {
+		return 0, err
+	}
+	return parsedTime.Hour()*60 + parsedTime.Minute(), nil
+}
+
+// Derives a meeting modality from a section's instruction mode, i.e. "Face-to-Face" or "Online"
+func modalityFromInstructionMode(instructionMode string) string {
+	lowerMode := strings.ToLower(instructionMode)
+	switch {
+	case strings.Contains(lowerMode, "hybrid") || strings.Contains(lowerMode, "blended"):
+		return MODALITY_HYBRID
+	case onlineLocationRegexp.MatchString(lowerMode):
+		return MODALITY_ONLINE
+	case offCampusLocationRegexp.MatchString(lowerMode):
+		return MODALITY_OFF_CAMPUS
+	default:
+		return MODALITY_IN_PERSON
+	}
+}
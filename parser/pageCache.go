@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Bump this whenever what's read from a page changes, so stale cache entries are ignored
+const PAGE_CACHE_VERSION = 1
+
+// What's cached for a single page; the path isn't cached, since identical pages can live at different paths
+type cachedPage struct {
+	RowInfo     map[string]string `json:"row_info"`
+	ClassInfo   map[string]string `json:"class_info"`
+	SyllabusURI string            `json:"syllabus_uri"`
+}
+
+// Makes the cache key for a page from a hash of its content
+func pageCacheKey(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// Gets the path of the cache entry for the page with the given key
+func cachedPagePath(cacheDir string, cacheKey string) string {
+	return filepath.Join(cacheDir, fmt.Sprintf("v%d", PAGE_CACHE_VERSION), cacheKey[:2], cacheKey+".json")
+}
+
+// Loads the cached page with the given key, attributing it to path
+func loadCachedPage(cacheDir string, cacheKey string, path string) (*sectionPage, error) {
+	var cached cachedPage
+	if err := readJSONFile(cachedPagePath(cacheDir, cacheKey), &cached); err != nil {
+		return nil, err
+	}
+	return &sectionPage{
+		path:        path,
+		rowInfo:     cached.RowInfo,
+		classInfo:   cached.ClassInfo,
+		syllabusURI: cached.SyllabusURI,
+	}, nil
+}
+
+// Stores a page in the cache under the given key
+func storeCachedPage(cacheDir string, cacheKey string, page *sectionPage) error {
+	cachePath := cachedPagePath(cacheDir, cacheKey)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0777); err != nil {
+		return err
+	}
+	content, err := json.Marshal(cachedPage{
+		RowInfo:     page.rowInfo,
+		ClassInfo:   page.classInfo,
+		SyllabusURI: page.syllabusURI,
+	})
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so a half-written entry is never read, even if two workers cache identical pages at once
+	tempFile, err := os.CreateTemp(filepath.Dir(cachePath), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), cachePath)
+}
+
+// Decodes the JSON file at path into data
+func readJSONFile(path string, data interface{}) error {
+	fptr, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fptr.Close()
+	return json.NewDecoder(fptr).Decode(data)
+}
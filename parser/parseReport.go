@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned (wrapped) by Parser.ParseFiles when more files fail to parse than the parser allows
+var ErrTooManyFailures = errors.New("too many files failed to parse")
+
+// Error for a coursebook page field that's missing or malformed
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("field '%s': %s", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Makes a FieldError for the given field with a formatted message
+func fieldErrorf(field string, format string, vars ...any) *FieldError {
+	return &FieldError{Field: field, Err: fmt.Errorf(format, vars...)}
+}
+
+// A single file that failed to parse
+type FileFailure struct {
+	Path  string `json:"path"`
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// A section whose instructors in the grade data don't match its instructors in coursebook
+type InstructorMismatch struct {
+	Section               string   `json:"section"`
+	GradeInstructors      []string `json:"grade_instructors"`
+	CoursebookInstructors []string `json:"coursebook_instructors"`
+}
+
+// A section meeting that couldn't be (fully) parsed
+type MeetingFailure struct {
+	Section string `json:"section"`
+	Text    string `json:"text"`
+	Error   string `json:"error"`
+}
+
+// Summary of a parse, listing every file that failed to parse, every meeting that couldn't be parsed, and every section whose grade data disagrees with coursebook about its instructors.
+// Files for terms that weren't being parsed are counted as skipped.
+type ParseReport struct {
+	Files                int                  `json:"files"`
+	Parsed               int                  `json:"parsed"`
+	Skipped              int                  `json:"skipped"`
+	Failures             []FileFailure        `json:"failures"`
+	UnparsedMeetings     []MeetingFailure     `json:"unparsed_meetings"`
+	InstructorMismatches []InstructorMismatch `json:"instructor_mismatches"`
+	// Sections made from grade data alone, and grade rows that couldn't be made into sections because their course was never parsed
+	GradeOnlySections      int `json:"grade_only_sections"`
+	GradeRowsWithoutCourse int `json:"grade_rows_without_course"`
+}
+
+// Records a file that failed to parse, pulling the offending field out of the error if there is one
+func (report *ParseReport) addFailure(path string, err error) {
+	failure := FileFailure{Path: path, Error: err.Error()}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		failure.Field = fieldErr.Field
+		failure.Error = fieldErr.Err.Error()
+	}
+	report.Failures = append(report.Failures, failure)
+}
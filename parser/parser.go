@@ -1,10 +1,15 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/UTDNebula/api-tools/utils"
@@ -14,102 +19,411 @@ import (
 	"github.com/UTDNebula/nebula-api/api/schema"
 )
 
-// Main dictionaries for mapping unique keys to the actual data
-var Sections = make(map[primitive.ObjectID]*schema.Section)
-var Courses = make(map[string]*schema.Course)
-var Professors = make(map[string]*schema.Professor)
+// Time location for dates (uses America/Chicago tz database zone for CDT which accounts for daylight saving)
+var timeLocation, timeError = time.LoadLocation("America/Chicago")
 
-// Auxilliary dictionaries for mapping the generated ObjectIDs to the keys used in the above maps, used for validation purposes
-var CourseIDMap = make(map[primitive.ObjectID]string)
-var ProfessorIDMap = make(map[primitive.ObjectID]string)
+// Parser for scraped coursebook data. A Parser owns all of the state for a parse, so multiple Parsers can be used independently in one process.
+type Parser struct {
+	// Main dictionaries for mapping unique keys to the actual data
+	sections   map[primitive.ObjectID]*schema.Section
+	courses    map[string]*schema.Course
+	professors map[string]*schema.Professor
+
+	// Auxilliary dictionaries for mapping the generated ObjectIDs to the keys used in the above maps, used for validation purposes
+	courseIDMap    map[primitive.ObjectID]string
+	professorIDMap map[primitive.ObjectID]string
+
+	// Requisite parser closures associated with courses
+	reqParsers map[primitive.ObjectID]func()
+	// Requisite parser closures associated with sections, which depend on their course's requisites being parsed first
+	sectionReqParsers map[primitive.ObjectID]func()
+	// Enrollment reqs text of each of a course's sections, and the requisite chunks all of them share
+	courseReqTexts  map[primitive.ObjectID][]string
+	courseReqChunks map[primitive.ObjectID]*requisiteChunks
+
+	// Grade mappings for section grade distributions, mapping is MAP[SEMESTER] -> MAP[SUBJECT + NUMBER + SECTION] -> GRADE RECORD
+	gradeMap map[string]map[string]*gradeRecord
+	// Rows of each term's grade data that were replaced by a later row with the same key
+	gradeDuplicates map[string][]*gradeRecord
+
+	// Requisite matchers, in order of precedence
+	matchers []Matcher
+	// This is the list of produced requisites. Indices coincide with group indices -- aka group @0 will also be the 0th index of the list since it will be processed first.
+	requisiteList []interface{}
+	// This is the list of groups that are to be parsed. They are the raw text chunks associated with the reqs above.
+	groupList []string
+
+	// Keys of the sections each section's page says it's cross-listed with
+	crossListKeys map[primitive.ObjectID][]string
+
+	// Report for the parse in progress
+	report *ParseReport
+	// Undoes whatever the page being parsed has created so far, in reverse order, if it fails partway through
+	pageUndo []func()
+	// Grades held back for having too few students
+	suppressedGrades []SuppressedGrades
+
+	// Options
+	csvDir        string
+	cacheDir      string
+	profileDir    string
+	doValidation  bool
+	maxFailures   int
+	dropCancelled bool
+	terms         map[string]bool
+	perTermOutput bool
+	format        string
+
+	gradeOnlySections bool
+	gpaWeights        map[string]float64
+	minCohort         int
+	suppressionMode   string
+}
 
-// Requisite parser closures associated with courses
-var ReqParsers = make(map[primitive.ObjectID]func())
+// Option for configuring a Parser
+type Option func(*Parser)
 
-// Grade mappings for section grade distributions, mapping is MAP[SEMESTER] -> MAP[SUBJECT + NUMBER + SECTION] -> GRADE DISTRIBUTION
-var GradeMap map[string]map[string][]int
+// Attaches grade distributions from the CSV files in csvDir to parsed sections
+func WithGrades(csvDir string) Option {
+	return func(p *Parser) {
+		p.csvDir = csvDir
+	}
+}
 
-// Time location for dates (uses America/Chicago tz database zone for CDT which accounts for daylight saving)
-var timeLocation, timeError = time.LoadLocation("America/Chicago")
+// Seeds professors with the profiles.json in profileDir, if there is one
+func WithProfiles(profileDir string) Option {
+	return func(p *Parser) {
+		p.profileDir = profileDir
+	}
+}
+
+// Enables or disables post-parsing validation, which is enabled by default
+func WithValidation(validate bool) Option {
+	return func(p *Parser) {
+		p.doValidation = validate
+	}
+}
+
+// Sets how many files may fail to parse before the whole parse fails, which is 0 by default
+func WithMaxFailures(maxFailures int) Option {
+	return func(p *Parser) {
+		p.maxFailures = maxFailures
+	}
+}
+
+// Has validation drop cancelled sections instead of only flagging them
+func WithDropCancelled(dropCancelled bool) Option {
+	return func(p *Parser) {
+		p.dropCancelled = dropCancelled
+	}
+}
+
+// Caches what's read from each page in cacheDir, keyed by the page's content, so unchanged pages don't have to be read again
+func WithCache(cacheDir string) Option {
+	return func(p *Parser) {
+		p.cacheDir = cacheDir
+	}
+}
+
+// Sets the format of the data files the parser reads (profiles) and Parse writes (courses, sections, and professors), which is JSON by default
+func WithFormat(format string) Option {
+	return func(p *Parser) {
+		p.format = format
+	}
+}
+
+// Constructor for parser.Parser
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{doValidation: true, format: utils.FORMAT_JSON, gpaWeights: DefaultGPAWeights}
+	for _, opt := range opts {
+		opt(p)
+	}
+	// Initialize matchers at runtime for requisite parsing; this is necessary to avoid circular reference errors with compile-time initialization
+	p.initMatchers()
+	return p
+}
+
+// Data produced by a parse
+type Result struct {
+	Courses    []*schema.Course
+	Sections   []*schema.Section
+	Professors []*schema.Professor
+	Report     *ParseReport
+	// Nil if there was no grade data
+	GradeReport *GradeReport
+	GradeStats  []*GradeStats
+}
 
-// Externally exposed parse function
-func Parse(inDir string, outDir string, csvPath string, skipValidation bool) {
+// Externally exposed parse function; parses all data in inDir with the given options and writes the results to outDir.
+// Exits with a non-zero status if more files fail to parse than the options allow.
+func Parse(inDir string, outDir string, opts ...Option) {
+
+	p := NewParser(append([]Option{WithProfiles(inDir)}, opts...)...)
+	result, parseErr := p.ParseDir(inDir)
+
+	// Make outDir if it doesn't already exist
+	err := os.MkdirAll(outDir, 0777)
+	if err != nil {
+		panic(err)
+	}
+
+	// Always write the report, so failures can be looked into
+	reportPath := fmt.Sprintf("%s/parse_report.json", outDir)
+	if err := utils.WriteJSON(reportPath, result.Report); err != nil {
+		panic(err)
+	}
+	if len(result.Report.Failures) > 0 {
+		log.Printf("%d of %d files failed to parse. See %s for details.", len(result.Report.Failures), result.Report.Files, reportPath)
+	}
+	if result.GradeReport != nil {
+		if err := utils.WriteJSON(fmt.Sprintf("%s/grade_report.json", outDir), result.GradeReport); err != nil {
+			panic(err)
+		}
+		if err := utils.WriteDataFile(outDir, "grade_stats", p.format, result.GradeStats); err != nil {
+			panic(err)
+		}
+	}
+
+	if errors.Is(parseErr, ErrTooManyFailures) {
+		log.Fatalf("PARSING FAILED: %s", parseErr)
+	} else if parseErr != nil {
+		log.Printf("VALIDATION FAILED: %s", parseErr)
+	}
+
+	// Write validated data to output files
+	if err := writeResult(outDir, p.format, result.Courses, result.Sections, result.Professors); err != nil {
+		panic(err)
+	}
+
+	if p.perTermOutput {
+		if err := writeTermOutputs(outDir, p.format, result); err != nil {
+			panic(err)
+		}
+	}
+	// Courses and professors only list the sections of the terms that were parsed, so uploading them as is would drop their other terms' sections
+	if len(p.terms) != 0 {
+		log.Print("Only some terms were parsed, so upload this data with -termsubset to keep the sections of other terms.")
+	} else if p.perTermOutput {
+		log.Print("Upload each term's directory with -termsubset to keep the sections of other terms.")
+	}
+}
+
+// Writes courses, sections, and professors to their data files in outDir
+func writeResult(outDir string, format string, courses []*schema.Course, sections []*schema.Section, professors []*schema.Professor) error {
+	if err := utils.WriteDataFile(outDir, "courses", format, courses); err != nil {
+		return err
+	}
+	if err := utils.WriteDataFile(outDir, "sections", format, sections); err != nil {
+		return err
+	}
+	return utils.WriteDataFile(outDir, "professors", format, professors)
+}
+
+// Parses all of the coursebook pages in inDir
+func (p *Parser) ParseDir(inDir string) (*Result, error) {
+	return p.ParseFiles(utils.GetAllFilesWithExtension(inDir, ".html"))
+}
+
+// Parses the coursebook pages at the given paths.
+// Files that fail to parse are skipped and listed in the result's report; if more files fail than the parser allows, the error wraps ErrTooManyFailures.
+// Any state from a previous parse is discarded. If parsing or validation fails, the result is still returned alongside the error.
+func (p *Parser) ParseFiles(paths []string) (*Result, error) {
 
 	// Panic if timeLocation didn't load properly
 	if timeError != nil {
 		panic(timeError)
 	}
 
+	p.reset()
+	paths = p.filterTermPaths(paths)
+
 	// Load grade data from csv in advance
-	GradeMap = loadGrades(csvPath)
-	if len(GradeMap) != 0 {
-		log.Printf("Loaded grade distributions for %d semesters.", len(GradeMap))
+	p.gradeMap, p.gradeDuplicates = loadGrades(p.csvDir)
+	if len(p.gradeMap) != 0 {
+		log.Printf("Loaded grade distributions for %d semesters.", len(p.gradeMap))
 	}
 
 	// Try to load any existing profile data
-	loadProfiles(inDir)
+	if p.profileDir != "" {
+		p.loadProfiles(p.profileDir)
+	}
 
-	// Find paths of all scraped data
-	paths := utils.GetAllFilesWithExtension(inDir, ".html")
-	if !skipValidation {
+	if p.doValidation {
 		log.Printf("Parsing and validating %d files...", len(paths))
 	} else {
 		log.Printf("Parsing %d files WITHOUT VALIDATION...", len(paths))
 	}
 
-	// Parse all data
-	for _, path := range paths {
-		parse(path)
+	// Parse all data; documents are read in parallel, but merged in path order so results match a serial run
+	p.report = &ParseReport{Files: len(paths), Failures: []FileFailure{}, UnparsedMeetings: []MeetingFailure{}, InstructorMismatches: []InstructorMismatch{}}
+	report := p.report
+	pages, readErrs := p.readSectionPages(paths)
+	for i, page := range pages {
+		err := readErrs[i]
+		if err == nil {
+			if !p.includesPage(page) {
+				report.Skipped++
+				continue
+			}
+			err = p.parse(page)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to parse %s: %s", paths[i], err)
+			report.addFailure(paths[i], err)
+			continue
+		}
+		report.Parsed++
 	}
 
-	log.Printf("\nParsing complete. Created %d courses, %d sections, and %d professors.", len(Courses), len(Sections), len(Professors))
+	log.Printf("\nParsing complete. Created %d courses, %d sections, and %d professors.", len(p.courses), len(p.sections), len(p.professors))
 
 	log.Print("\nParsing course requisites...")
+	for _, course := range p.courses {
+		p.reqParsers[course.Id]()
+	}
+	for _, section := range p.sections {
+		if sectionReqParser, exists := p.sectionReqParsers[section.Id]; exists {
+			sectionReqParser()
+		}
+	}
+	log.Print("Finished parsing course requisites!")
 
-	// Initialize matchers at runtime for requisite parsing; this is necessary to avoid circular reference errors with compile-time initialization
-	initMatchers()
+	p.attributeGradeInstructors()
+	if p.gradeOnlySections {
+		p.addGradeOnlySections()
+	}
 
-	for _, course := range Courses {
-		ReqParsers[course.Id]()
+	p.linkCourseVersions()
+
+	// Dropping cancelled sections was asked for on its own, so it's done even if validation is skipped
+	if p.doValidation || p.dropCancelled {
+		p.checkCancelledSections()
 	}
-	log.Print("Finished parsing course requisites!")
 
-	if !skipValidation {
+	p.linkCrossLists()
+
+	result := &Result{
+		Courses:    utils.GetMapValues(p.courses),
+		Sections:   utils.GetMapValues(p.sections),
+		Professors: utils.GetMapValues(p.professors),
+		Report:     report,
+	}
+	if len(p.gradeMap) != 0 {
+		result.GradeStats = p.suppressGradeStats(p.computeGradeStats())
+		result.GradeReport = p.reconcileGrades()
+	}
+
+	if len(report.Failures) > p.maxFailures {
+		return result, fmt.Errorf("%w: %d of %d files failed, but at most %d may fail", ErrTooManyFailures, len(report.Failures), len(paths), p.maxFailures)
+	}
+
+	if p.doValidation {
 		log.Print("\nStarting validation stage...")
-		validate()
+		if err := p.validate(); err != nil {
+			return result, err
+		}
 		log.Print("\nValidation complete!")
 	}
 
-	// Make outDir if it doesn't already exist
-	err := os.MkdirAll(outDir, 0777)
-	if err != nil {
-		panic(err)
-	}
+	return result, nil
+}
 
-	// Write validated data to output files
-	utils.WriteJSON(fmt.Sprintf("%s/courses.json", outDir), utils.GetMapValues(Courses))
-	utils.WriteJSON(fmt.Sprintf("%s/sections.json", outDir), utils.GetMapValues(Sections))
-	utils.WriteJSON(fmt.Sprintf("%s/professors.json", outDir), utils.GetMapValues(Professors))
+// Clears all state from any previous parse
+func (p *Parser) reset() {
+	p.sections = make(map[primitive.ObjectID]*schema.Section)
+	p.courses = make(map[string]*schema.Course)
+	p.professors = make(map[string]*schema.Professor)
+	p.courseIDMap = make(map[primitive.ObjectID]string)
+	p.professorIDMap = make(map[primitive.ObjectID]string)
+	p.reqParsers = make(map[primitive.ObjectID]func())
+	p.sectionReqParsers = make(map[primitive.ObjectID]func())
+	p.courseReqTexts = make(map[primitive.ObjectID][]string)
+	p.courseReqChunks = make(map[primitive.ObjectID]*requisiteChunks)
+	p.crossListKeys = make(map[primitive.ObjectID][]string)
+	p.requisiteList = nil
+	p.groupList = nil
+	p.suppressedGrades = []SuppressedGrades{}
 }
 
-// Internal parse function
-func parse(path string) {
+// Data read from a single coursebook section page
+type sectionPage struct {
+	path        string
+	rowInfo     map[string]string
+	classInfo   map[string]string
+	syllabusURI string
+}
 
-	utils.VPrintf("Parsing %s...", path)
+// Reads the section pages at the given paths across a pool of workers, returning them and any errors reading them in the same order as paths.
+// If the parser has a cache, unchanged pages are loaded from it instead of being read again.
+func (p *Parser) readSectionPages(paths []string) ([]*sectionPage, []error) {
+	pages := make([]*sectionPage, len(paths))
+	errs := make([]error, len(paths))
+	var cacheHits atomic.Int64
+	pathIndices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range pathIndices {
+				// Each worker writes to its own indices, so no locking is needed
+				var cached bool
+				pages[index], cached, errs[index] = p.readSectionPage(paths[index])
+				if cached {
+					cacheHits.Add(1)
+				}
+			}
+		}()
+	}
+	for index := range paths {
+		pathIndices <- index
+	}
+	close(pathIndices)
+	wg.Wait()
+	if p.cacheDir != "" {
+		log.Printf("Reused %d of %d pages from the parse cache.", cacheHits.Load(), len(paths))
+	}
+	return pages, errs
+}
 
-	// Open data file for reading
-	fptr, err := os.Open(path)
+// Reads the section page at the given path, reporting whether it was loaded from the parse cache
+func (p *Parser) readSectionPage(path string) (*sectionPage, bool, error) {
+
+	utils.VPrintf("Reading %s...", path)
+
+	content, err := os.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return nil, false, err
 	}
-	defer fptr.Close()
 
-	// Create a goquery document for HTML parsing
-	doc, err := goquery.NewDocumentFromReader(fptr)
+	if p.cacheDir == "" {
+		page, err := parseSectionPage(path, content)
+		return page, false, err
+	}
+
+	cacheKey := pageCacheKey(content)
+	if page, err := loadCachedPage(p.cacheDir, cacheKey, path); err == nil {
+		return page, true, nil
+	}
+	page, err := parseSectionPage(path, content)
 	if err != nil {
-		panic(err)
+		return nil, false, err
 	}
+	// A page that can't be cached can still be parsed, so only warn
+	if err := storeCachedPage(p.cacheDir, cacheKey, page); err != nil {
+		log.Printf("WARN: Couldn't cache %s: %s", path, err)
+	}
+	return page, false, nil
+}
+
+// Reads the info tables out of a section page's HTML
+func parseSectionPage(path string, content []byte) (*sectionPage, error) {
 
+	// Create a goquery document for HTML parsing
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
 	// Get the rows of the info table
 	infoTable := doc.FindMatcher(goquery.Single("table.courseinfo__overviewtable > tbody"))
 	infoRows := infoTable.ChildrenFiltered("tr")
@@ -147,16 +461,55 @@ func parse(path string) {
 		})
 	})
 
+	return &sectionPage{
+		path:        path,
+		rowInfo:     rowInfo,
+		classInfo:   classInfo,
+		syllabusURI: syllabusURI,
+	}, nil
+}
+
+// Internal parse function
+func (p *Parser) parse(page *sectionPage) (err error) {
+
+	utils.VPrintf("Parsing %s...", page.path)
+
+	// Anything unexpected that goes wrong is reported as a failure of this file alone, and whatever the file created is undone
+	p.pageUndo = nil
+	defer func() {
+		if failure := recover(); failure != nil {
+			err = fmt.Errorf("%v", failure)
+			for i := len(p.pageUndo) - 1; i >= 0; i-- {
+				p.pageUndo[i]()
+			}
+		}
+	}()
+
+	rowInfo := page.rowInfo
+	classInfo := page.classInfo
+
+	// Make sure the fields used as keys are usable before creating anything, so a bad page doesn't leave partial data behind
+	if !coursePrefixRexp.MatchString(classInfo["Class Section:"]) || !sectionPrefixRegexp.MatchString(classInfo["Class Section:"]) {
+		return fieldErrorf("Class Section:", "couldn't find a section ID in '%s'", classInfo["Class Section:"])
+	}
+
 	// Get the class and course num by splitting classInfo value
 	classAndCourseNum := strings.Split(classInfo["Class/Course Number:"], " / ")
+	if len(classAndCourseNum) != 2 {
+		return fieldErrorf("Class/Course Number:", "expected '<class number> / <course number>', got '%s'", classInfo["Class/Course Number:"])
+	}
 	classNum := classAndCourseNum[0]
 	courseNum := utils.TrimWhitespace(classAndCourseNum[1])
 
 	// Figure out the academic session associated with this specific course/Section
-	session := getAcademicSession(rowInfo)
+	session, err := getAcademicSession(rowInfo)
+	if err != nil {
+		return err
+	}
 
 	// Try to create the course and section based on collected info
-	courseRef := parseCourse(courseNum, session, rowInfo, classInfo)
-	parseSection(courseRef, classNum, syllabusURI, session, rowInfo, classInfo)
+	courseRef := p.parseCourse(courseNum, session, rowInfo, classInfo)
+	p.parseSection(courseRef, classNum, page.syllabusURI, session, rowInfo, classInfo)
 	utils.VPrint("Parsed!")
+	return nil
 }
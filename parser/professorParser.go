@@ -8,7 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func parseProfessors(sectionId primitive.ObjectID, rowInfo map[string]string, classInfo map[string]string) []primitive.ObjectID {
+func (p *Parser) parseProfessors(sectionId primitive.ObjectID, rowInfo map[string]string, classInfo map[string]string) []primitive.ObjectID {
 	professorText := rowInfo["Instructor(s):"]
 	professorMatches := personRegexp.FindAllStringSubmatch(professorText, -1)
 	var profRefs []primitive.ObjectID = make([]primitive.ObjectID, 0, len(professorMatches))
@@ -27,23 +27,33 @@ func parseProfessors(sectionId primitive.ObjectID, rowInfo map[string]string, cl
 
 		profKey := firstName + lastName
 
-		prof, profExists := Professors[profKey]
+		prof, profExists := p.professors[profKey]
 		if profExists {
 			prof.Sections = append(prof.Sections, sectionId)
 			profRefs = append(profRefs, prof.Id)
+			p.pageUndo = append(p.pageUndo, func() { prof.Sections = prof.Sections[:len(prof.Sections)-1] })
 			continue
 		}
 
 		prof = &schema.Professor{}
-		prof.Id = primitive.NewObjectID()
+		prof.Id = professorID(firstName, lastName)
 		prof.First_name = firstName
 		prof.Last_name = lastName
 		prof.Titles = []string{utils.TrimWhitespace(match[2])}
 		prof.Email = utils.TrimWhitespace(match[3])
 		prof.Sections = []primitive.ObjectID{sectionId}
 		profRefs = append(profRefs, prof.Id)
-		Professors[profKey] = prof
-		ProfessorIDMap[prof.Id] = profKey
+		p.professors[profKey] = prof
+		p.professorIDMap[prof.Id] = profKey
+		p.pageUndo = append(p.pageUndo, func() {
+			delete(p.professors, profKey)
+			delete(p.professorIDMap, prof.Id)
+		})
 	}
 	return profRefs
 }
+
+// IDs are derived from the professor's name, which is also how professors are matched to their profiles, so they stay the same across runs
+func professorID(firstName string, lastName string) primitive.ObjectID {
+	return utils.DeterministicID("professor", firstName, lastName)
+}
@@ -1,51 +1,35 @@
 package parser
 
 import (
-	"encoding/json"
-	"fmt"
 	"log"
 	"os"
 
+	"github.com/UTDNebula/api-tools/utils"
 	"github.com/UTDNebula/nebula-api/api/schema"
 )
 
-func loadProfiles(inDir string) {
-	fptr, err := os.Open(fmt.Sprintf("%s/profiles.json", inDir))
-	if err != nil {
-		log.Print("Couldn't find/open profiles.json in the input directory. Skipping profile load.")
+func (p *Parser) loadProfiles(inDir string) {
+	profilesPath := utils.DataFilePath(inDir, "profiles", p.format)
+	if _, err := os.Stat(profilesPath); err != nil {
+		log.Printf("Couldn't find/open %s. Skipping profile load.", profilesPath)
 		return
 	}
 
-	decoder := json.NewDecoder(fptr)
-
 	log.Print("Beginning profile load.")
 
-	// Read open bracket
-	_, err = decoder.Token()
-	if err != nil {
-		panic(err)
-	}
-
-	// While the array contains values
 	profileCount := 0
-	for ; decoder.More(); profileCount++ {
-		// Decode a professor
-		var prof schema.Professor
-		err := decoder.Decode(&prof)
-		if err != nil {
-			panic(err)
-		}
+	err := utils.ReadDataFile(profilesPath, func(prof schema.Professor) error {
+		// Profiles may have been scraped with random IDs, so re-derive them to match the rest of the parser's output
+		prof.Id = professorID(prof.First_name, prof.Last_name)
 		professorKey := prof.First_name + prof.Last_name
-		Professors[professorKey] = &prof
-		ProfessorIDMap[prof.Id] = professorKey
-	}
-
-	// Read closing bracket
-	_, err = decoder.Token()
+		p.professors[professorKey] = &prof
+		p.professorIDMap[prof.Id] = professorKey
+		profileCount++
+		return nil
+	})
 	if err != nil {
 		panic(err)
 	}
 
 	log.Printf("Loaded %d profiles!", profileCount)
-	fptr.Close()
 }
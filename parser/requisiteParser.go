@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -29,12 +30,12 @@ type Matcher struct {
 
 var ANDRegex = regexp.MustCompile(`(?i)\s+and\s+`)
 
-func ANDMatcher(group string, subgroups []string) interface{} {
+func (p *Parser) ANDMatcher(group string, subgroups []string) interface{} {
 	// Split text along " and " boundaries, then parse subexpressions as groups into an "AND" CollectionRequirement
 	subExpressions := ANDRegex.Split(group, -1)
 	parsedSubExps := make([]interface{}, 0, len(subExpressions))
 	for _, exp := range subExpressions {
-		parsedExp := parseGroup(utils.TrimWhitespace(exp))
+		parsedExp := p.parseGroup(utils.TrimWhitespace(exp))
 		// Don't include throwaways
 		if !reqIsThrowaway(parsedExp) {
 			parsedSubExps = append(parsedSubExps, parsedExp)
@@ -56,26 +57,26 @@ func ANDMatcher(group string, subgroups []string) interface{} {
 // Resulting substituted text would be: "(OPRE 3360 or STAT 3360 or STAT 4351), and @N", where N is some group number
 // When @N is dereferenced from the requisite list, it will have a value equivalent to the result of parseFnc(group, subgroups)
 
-func SubstitutionMatcher(parseFnc func(string, []string) interface{}) func(string, []string) interface{} {
+func (p *Parser) SubstitutionMatcher(parseFnc func(string, []string) interface{}) func(string, []string) interface{} {
 	// Return a closure that uses parseFnc to substitute subgroups[1]
 	return func(group string, subgroups []string) interface{} {
 		// If there's no text to substitute, just return an OtherRequirement
 		if len(subgroups) < 2 {
-			return OtherMatcher(group, subgroups)
+			return p.OtherMatcher(group, subgroups)
 		}
 		// Otherwise, substitute subgroups[1] and parse it with parseFnc
-		return parseGroup(makeSubgroup(group, subgroups[1], parseFnc(group, subgroups)))
+		return p.parseGroup(p.makeSubgroup(group, subgroups[1], parseFnc(group, subgroups)))
 	}
 }
 
 var ORRegex = regexp.MustCompile(`(?i)\s+or\s+`)
 
-func ORMatcher(group string, subgroups []string) interface{} {
+func (p *Parser) ORMatcher(group string, subgroups []string) interface{} {
 	// Split text along " or " boundaries, then parse subexpressions as groups into an "OR" CollectionRequirement
 	subExpressions := ORRegex.Split(group, -1)
 	parsedSubExps := make([]interface{}, 0, len(subExpressions))
 	for _, exp := range subExpressions {
-		parsedExp := parseGroup(utils.TrimWhitespace(exp))
+		parsedExp := p.parseGroup(utils.TrimWhitespace(exp))
 		// Don't include throwaways
 		if !reqIsThrowaway(parsedExp) {
 			parsedSubExps = append(parsedSubExps, parsedExp)
@@ -91,20 +92,20 @@ func ORMatcher(group string, subgroups []string) interface{} {
 	}
 }
 
-func CourseMinGradeMatcher(group string, subgroups []string) interface{} {
-	icn, err := findICN(subgroups[1], subgroups[2])
+func (p *Parser) CourseMinGradeMatcher(group string, subgroups []string) interface{} {
+	icn, err := p.findICN(subgroups[1], subgroups[2])
 	if err != nil {
 		log.Printf("WARN: %s", err)
-		return OtherMatcher(group, subgroups)
+		return p.OtherMatcher(group, subgroups)
 	}
 	return schema.NewCourseRequirement(icn, subgroups[3])
 }
 
-func CourseMatcher(group string, subgroups []string) interface{} {
-	icn, err := findICN(subgroups[1], subgroups[2])
+func (p *Parser) CourseMatcher(group string, subgroups []string) interface{} {
+	icn, err := p.findICN(subgroups[1], subgroups[2])
 	if err != nil {
 		log.Printf("WARN: %s", err)
-		return OtherMatcher(group, subgroups)
+		return p.OtherMatcher(group, subgroups)
 	}
 	return schema.NewCourseRequirement(icn, "D")
 }
@@ -145,11 +146,11 @@ func CoreCompletionMatcher(group string, subgroups []string) interface{} {
 	return schema.NewCoreRequirement(subgroups[1], -1)
 }
 
-func ChoiceMatcher(group string, subgroups []string) interface{} {
-	collectionReq, ok := parseGroup(subgroups[1]).(*schema.CollectionRequirement)
+func (p *Parser) ChoiceMatcher(group string, subgroups []string) interface{} {
+	collectionReq, ok := p.parseGroup(subgroups[1]).(*schema.CollectionRequirement)
 	if !ok {
 		log.Printf("WARN: ChoiceMatcher wasn't able to parse subgroup '%s' into a CollectionRequirement!", subgroups[1])
-		return OtherMatcher(group, subgroups)
+		return p.OtherMatcher(group, subgroups)
 	}
 	return schema.NewChoiceRequirement(collectionReq)
 }
@@ -169,33 +170,31 @@ func ThrowawayMatcher(group string, subgroups []string) interface{} {
 // Regex for group tags
 var groupTagRegex = regexp.MustCompile(`@(\d+)`)
 
-func GroupTagMatcher(group string, subgroups []string) interface{} {
+func (p *Parser) GroupTagMatcher(group string, subgroups []string) interface{} {
 	groupIndex, err := strconv.Atoi(subgroups[1])
 	if err != nil {
 		panic(err)
 	}
 	// Return a throwaway if index is out of range
-	if groupIndex < 0 || groupIndex >= len(requisiteList) {
+	if groupIndex < 0 || groupIndex >= len(p.requisiteList) {
 		return schema.Requirement{Type: "throwaway"}
 	}
 	// Find referenced group and return it
-	parsedGrp := requisiteList[groupIndex]
+	parsedGrp := p.requisiteList[groupIndex]
 	return parsedGrp
 }
 
-func OtherMatcher(group string, subgroups []string) interface{} {
-	return schema.NewOtherRequirement(ungroupText(group), "")
+func (p *Parser) OtherMatcher(group string, subgroups []string) interface{} {
+	return schema.NewOtherRequirement(p.ungroupText(group), "")
 }
 
 /////////////////////// END MATCHER FUNCS ///////////////////////
 
-// Matcher container, matchers must be in order of precedence
+// Builds the parser's matcher container, matchers must be in order of precedence
 // NOTE: PARENTHESES ARE OF HIGHEST PRECEDENCE! (This is due to groupParens() handling grouping of parenthesized text before parsing begins)
-var Matchers []Matcher
-
 // Must init matchers via function at runtime to avoid compile-time circular definition error
-func initMatchers() {
-	Matchers = []Matcher{
+func (p *Parser) initMatchers() {
+	p.matchers = []Matcher{
 
 		// Throwaways
 		{
@@ -216,19 +215,19 @@ func initMatchers() {
 		// * <YEAR> only
 		{
 			utils.Regexpf(`(?i).+%s\s+only$`, utils.R_YEARS),
-			OtherMatcher,
+			p.OtherMatcher,
 		},
 
 		// * in any combination of *
 		{
 			regexp.MustCompile(`(?i).+\s+in\s+any\s+combination\s+of\s+.+`),
-			OtherMatcher,
+			p.OtherMatcher,
 		},
 
 		// <SUBJECT> majors and minors only
 		{
 			utils.Regexpf(`(?i)((%s)\s+majors\s+and\s+minors\s+only)`, utils.R_SUBJECT),
-			SubstitutionMatcher(func(group string, subgroups []string) interface{} {
+			p.SubstitutionMatcher(func(group string, subgroups []string) interface{} {
 				return MajorMinorMatcher(subgroups[1], subgroups[1:3])
 			}),
 		},
@@ -236,7 +235,7 @@ func initMatchers() {
 		// Completion of [a/an] <CORE CODE> core [course]
 		{
 			regexp.MustCompile(`(?i)(Completion\s+of\s+(?:an?\s+)?(\d{3}).+core(?:\s+course)?)`),
-			SubstitutionMatcher(func(group string, subgroups []string) interface{} {
+			p.SubstitutionMatcher(func(group string, subgroups []string) interface{} {
 				return CoreCompletionMatcher(subgroups[1], subgroups[1:3])
 			}),
 		},
@@ -244,57 +243,57 @@ func initMatchers() {
 		// Credit cannot be received for both [courses][,] <EXPRESSION>
 		{
 			regexp.MustCompile(`(?i)(Credit\s+cannot\s+be\s+received\s+for\s+both\s+(?:courses)?,?(.+))`),
-			SubstitutionMatcher(func(group string, subgroups []string) interface{} {
-				return ChoiceMatcher(subgroups[1], subgroups[1:3])
+			p.SubstitutionMatcher(func(group string, subgroups []string) interface{} {
+				return p.ChoiceMatcher(subgroups[1], subgroups[1:3])
 			}),
 		},
 
 		// Credit cannot be received for more than one of *: <EXPRESSION>
 		{
 			regexp.MustCompile(`(?i)(Credit\s+cannot\s+be\s+received\s+for\s+more\s+than\s+one\s+of.+:(.+))`),
-			SubstitutionMatcher(func(group string, subgroups []string) interface{} {
-				return ChoiceMatcher(subgroups[1], subgroups[1:3])
+			p.SubstitutionMatcher(func(group string, subgroups []string) interface{} {
+				return p.ChoiceMatcher(subgroups[1], subgroups[1:3])
 			}),
 		},
 
 		// Logical &
 		{
 			ANDRegex,
-			ANDMatcher,
+			p.ANDMatcher,
 		},
 
 		// "<COURSE> with a [grade] [of] <GRADE> or better"
 		{
 			utils.Regexpf(`^(?i)(%s\s+with\s+a(?:\s+grade)?(?:\s+of)?\s+(%s)\s+or\s+better)`, utils.R_SUBJ_COURSE_CAP, utils.R_GRADE), // [name, number, min grade]
-			SubstitutionMatcher(func(group string, subgroups []string) interface{} {
-				return CourseMinGradeMatcher(subgroups[1], subgroups[1:5])
+			p.SubstitutionMatcher(func(group string, subgroups []string) interface{} {
+				return p.CourseMinGradeMatcher(subgroups[1], subgroups[1:5])
 			}),
 		},
 
 		// Logical |
 		{
 			ORRegex,
-			ORMatcher,
+			p.ORMatcher,
 		},
 
 		// <COURSE> with a [minimum] grade of [at least] [a] <GRADE>
 		{
 			utils.Regexpf(`^(?i)%s\s+with\s+a\s+(?:minimum\s+)?grade\s+of\s+(?:at least\s+)?(?:a\s+)?(%s)$`, utils.R_SUBJ_COURSE_CAP, utils.R_GRADE), // [name, number, min grade]
-			CourseMinGradeMatcher,
+			p.CourseMinGradeMatcher,
 		},
 
 		// A grade of [at least] [a] <GRADE> in <COURSE>
 		{
 			utils.Regexpf(`^(?i)A\s+grade\s+of(?:\s+at\s+least)?(?:\s+a)?\s+(%s)\s+in\s+%s$`, utils.R_GRADE, utils.R_SUBJ_COURSE_CAP), // [min grade, name, number]
 			func(group string, subgroups []string) interface{} {
-				return CourseMinGradeMatcher(group, []string{subgroups[0], subgroups[2], subgroups[3], subgroups[1]})
+				return p.CourseMinGradeMatcher(group, []string{subgroups[0], subgroups[2], subgroups[3], subgroups[1]})
 			},
 		},
 
 		// <COURSE>
 		{
 			utils.Regexpf(`^\s*%s\s*$`, utils.R_SUBJ_COURSE_CAP), // [name, number]
-			CourseMatcher,
+			p.CourseMatcher,
 		},
 
 		// <GRANTER> consent required
@@ -354,7 +353,7 @@ func initMatchers() {
 		// Group tags (i.e. @1)
 		{
 			groupTagRegex, // [group #]
-			GroupTagMatcher,
+			p.GroupTagMatcher,
 		},
 	}
 }
@@ -367,53 +366,143 @@ var coreqRegexp *regexp.Regexp = regexp.MustCompile(`(?i)(Corequisites?:(.*))`)
 var reqRegexes [3]*regexp.Regexp = [3]*regexp.Regexp{preOrCoreqRegexp, prereqRegexp, coreqRegexp}
 
 // Returns a closure that parses the course's requisites
-func getReqParser(course *schema.Course, hasEnrollmentReqs bool, enrollmentReqs string) func() {
+func (p *Parser) getReqParser(course *schema.Course, hasEnrollmentReqs bool, enrollmentReqs string) func() {
 	return func() {
 		// Pointer array to course requisite properties must be in same order as reqRegexes above
 		courseReqs := [3]**schema.CollectionRequirement{&course.Co_or_pre_requisites, &course.Prerequisites, &course.Corequisites}
-		// The actual text to check for requisites
-		var checkText string
 		// Extract req text from the enrollment req info if it exists, otherwise try using the description
 		if hasEnrollmentReqs {
 			course.Enrollment_reqs = enrollmentReqs
-			checkText = enrollmentReqs
-		} else {
-			checkText = course.Description
 		}
-		// Iterate over and parse each type of requisite, populating the course's relevant requisite property
+		reqChunks := p.sharedRequisiteChunks(course).reqs
+		// Parse each type of requisite, populating the course's relevant requisite property
 		for index, reqPtr := range courseReqs {
-			reqMatches := reqRegexes[index].FindStringSubmatch(checkText)
-			if reqMatches != nil {
-				// Actual useful text is the inner match, index 2
-				reqText := reqMatches[2]
-				// Erase any sub-matches for other requisite types by matching outer text, index 1
-				for _, regex := range reqRegexes {
-					matches := regex.FindStringSubmatch(reqText)
-					if matches != nil {
-						reqText = strings.Replace(reqText, matches[1], "", -1)
-					}
-				}
-				// Erase current match from checkText to prevent erroneous duplicated Reqs
-				checkText = strings.Replace(checkText, reqMatches[1], "", -1)
-				// Split reqText into chunks based on period-space delimiters
-				textChunks := strings.Split(utils.TrimWhitespace(reqText), ". ")
-				parsedChunks := make([]interface{}, 0, len(textChunks))
-				// Parse each chunk, then add non-throwaway chunks to parsedChunks
-				for _, chunk := range textChunks {
-					// Trim any remaining rightmost periods
-					chunk = utils.TrimWhitespace(strings.TrimRight(chunk, "."))
-					parsedChunk := parseChunk(chunk)
-					if !reqIsThrowaway(parsedChunk) {
-						parsedChunks = append(parsedChunks, parsedChunk)
-					}
-				}
-				// Build CollectionRequirement from parsed chunks and apply to the course property
-				if len(parsedChunks) > 0 {
-					*reqPtr = schema.NewCollectionRequirement("REQUISITES", len(parsedChunks), parsedChunks)
-				}
+			if requisite := p.parseRequisiteChunks(reqChunks[index]); requisite != nil {
+				*reqPtr = requisite
+			}
+		}
+	}
+}
+
+// Returns a closure that parses the requisites and restrictions a section has on top of its course's; must be run after the course's requisite parser
+func (p *Parser) getSectionReqParser(section *schema.Section, course *schema.Course, enrollmentReqs string) func() {
+	return func() {
+		courseChunks := p.sharedRequisiteChunks(course)
+		sectionChunks, sectionRestrictions := splitRequisiteText(enrollmentReqs)
+
+		requisites := &SectionRequisites{}
+		sectionReqs := [3]**schema.CollectionRequirement{&requisites.Co_or_pre_requisites, &requisites.Prerequisites, &requisites.Corequisites}
+		for index, reqPtr := range sectionReqs {
+			*reqPtr = p.parseRequisiteChunks(filterChunks(sectionChunks[index], courseChunks.reqs[index], false))
+		}
+		requisites.Restrictions = p.parseRequisiteChunks(filterChunks(sectionRestrictions, courseChunks.restrictions, false))
+
+		if requisites.Co_or_pre_requisites == nil && requisites.Prerequisites == nil && requisites.Corequisites == nil && requisites.Restrictions == nil {
+			return
+		}
+		section.Section_corequisites = requisites.Corequisites
+		getSectionAttributes(section).Requisites = requisites
+	}
+}
+
+// Requisite text split into chunks for each type of requisite (in the same order as reqRegexes), along with chunks of any leftover restrictions
+type requisiteChunks struct {
+	reqs         [3][]string
+	restrictions []string
+}
+
+// Gets the requisite chunks that every one of the course's sections lists in its enrollment reqs, so that one section's own
+// restrictions (i.e. honors-only) aren't taken for the course's. Sections without enrollment reqs share none of them,
+// and courses whose sections all list no enrollment reqs fall back to their description.
+func (p *Parser) sharedRequisiteChunks(course *schema.Course) *requisiteChunks {
+	if shared, exists := p.courseReqChunks[course.Id]; exists {
+		return shared
+	}
+	// Sort the texts so the chunks come out in the same order no matter which section was parsed first
+	texts := append([]string{}, p.courseReqTexts[course.Id]...)
+	sort.Strings(texts)
+	if len(texts) == 0 || texts[len(texts)-1] == "" {
+		texts = []string{course.Description}
+	}
+
+	shared := &requisiteChunks{}
+	shared.reqs, shared.restrictions = splitRequisiteText(texts[0])
+	for _, text := range texts[1:] {
+		reqs, restrictions := splitRequisiteText(text)
+		for index := range shared.reqs {
+			shared.reqs[index] = filterChunks(shared.reqs[index], reqs[index], true)
+		}
+		shared.restrictions = filterChunks(shared.restrictions, restrictions, true)
+	}
+	p.courseReqChunks[course.Id] = shared
+	return shared
+}
+
+// Splits requisite text into chunks for each type of requisite (in the same order as reqRegexes), along with chunks of any leftover text
+func splitRequisiteText(checkText string) ([3][]string, []string) {
+	var reqChunks [3][]string
+	for index, regex := range reqRegexes {
+		reqMatches := regex.FindStringSubmatch(checkText)
+		if reqMatches == nil {
+			continue
+		}
+		// Actual useful text is the inner match, index 2
+		reqText := reqMatches[2]
+		// Erase any sub-matches for other requisite types by matching outer text, index 1
+		for _, regex := range reqRegexes {
+			matches := regex.FindStringSubmatch(reqText)
+			if matches != nil {
+				reqText = strings.Replace(reqText, matches[1], "", -1)
 			}
 		}
+		// Erase current match from checkText to prevent erroneous duplicated Reqs
+		checkText = strings.Replace(checkText, reqMatches[1], "", -1)
+		reqChunks[index] = splitRequisiteChunks(reqText)
 	}
+	return reqChunks, splitRequisiteChunks(checkText)
+}
+
+// Split text into chunks based on period-space delimiters
+func splitRequisiteChunks(text string) []string {
+	var chunks []string
+	for _, chunk := range strings.Split(utils.TrimWhitespace(text), ". ") {
+		// Trim any remaining rightmost periods
+		chunk = utils.TrimWhitespace(strings.TrimRight(chunk, "."))
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// Keeps only the chunks that also appear in others if shared is set, or only those that don't otherwise, ignoring case
+func filterChunks(chunks []string, others []string, shared bool) []string {
+	otherSet := make(map[string]bool, len(others))
+	for _, other := range others {
+		otherSet[strings.ToLower(other)] = true
+	}
+	var kept []string
+	for _, chunk := range chunks {
+		if otherSet[strings.ToLower(chunk)] == shared {
+			kept = append(kept, chunk)
+		}
+	}
+	return kept
+}
+
+// Parses each chunk, then builds a CollectionRequirement from the non-throwaway chunks, returning nil if there are none
+func (p *Parser) parseRequisiteChunks(chunks []string) *schema.CollectionRequirement {
+	parsedChunks := make([]interface{}, 0, len(chunks))
+	for _, chunk := range chunks {
+		parsedChunk := p.parseChunk(chunk)
+		if !reqIsThrowaway(parsedChunk) {
+			parsedChunks = append(parsedChunks, parsedChunk)
+		}
+	}
+	if len(parsedChunks) == 0 {
+		return nil
+	}
+	return schema.NewCollectionRequirement("REQUISITES", len(parsedChunks), parsedChunks)
 }
 
 // Function for pulling all requisite references (reqs referenced via group tags) from text
@@ -422,17 +511,17 @@ func getReqRefs(text string) []interface{} {
 	matches := groupTagRegex.FindAllStringSubmatch(text, -1)
 	refs := make([]interface{}, len(matches))
 	for i, submatches := range matches {
-		refs[i] = GroupTagMatcher(submatches[0], submatches)
+		refs[i] = p.GroupTagMatcher(submatches[0], submatches)
 	}
 	return refs
 }
 */
 
 // Function for creating a new group by replacing subtext in an existing group, and pushing the new group's info to the req and group list
-func makeSubgroup(group string, subtext string, requisite interface{}) string {
-	newGroup := strings.Replace(group, subtext, fmt.Sprintf("@%d", len(requisiteList)), -1)
-	requisiteList = append(requisiteList, requisite)
-	groupList = append(groupList, newGroup)
+func (p *Parser) makeSubgroup(group string, subtext string, requisite interface{}) string {
+	newGroup := strings.Replace(group, subtext, fmt.Sprintf("@%d", len(p.requisiteList)), -1)
+	p.requisiteList = append(p.requisiteList, requisite)
+	p.groupList = append(p.groupList, newGroup)
 	return newGroup
 }
 
@@ -472,8 +561,8 @@ func joinAdjacentOthers(reqs []interface{}, joinString string) []interface{} {
 }
 
 // Function for finding the Internal Course Number associated with the course with the specified subject and course number
-func findICN(subject string, number string) (string, error) {
-	for _, coursePtr := range Courses {
+func (p *Parser) findICN(subject string, number string) (string, error) {
+	for _, coursePtr := range p.courses {
 		if coursePtr.Subject_prefix == subject && coursePtr.Course_number == number {
 			return coursePtr.Internal_course_number, nil
 		}
@@ -481,18 +570,12 @@ func findICN(subject string, number string) (string, error) {
 	return "ERROR", fmt.Errorf("couldn't find an ICN for %s %s", subject, number)
 }
 
-// This is the list of produced requisites. Indices coincide with group indices -- aka group @0 will also be the 0th index of the list since it will be processed first.
-var requisiteList []interface{}
-
-// This is the list of groups that are to be parsed. They are the raw text chunks associated with the reqs above.
-var groupList []string
-
 // Innermost function for parsing individual text groups (used recursively by some Matchers)
-func parseGroup(grp string) interface{} {
+func (p *Parser) parseGroup(grp string) interface{} {
 	// Make sure we trim any mismatched right parentheses
 	grp = strings.TrimRight(grp, ")")
 	// Find an applicable matcher in Matchers
-	for _, matcher := range Matchers {
+	for _, matcher := range p.matchers {
 		matches := matcher.Regex.FindStringSubmatch(grp)
 		if matches != nil {
 			// If an applicable matcher has been found, return the result of calling its handler
@@ -503,26 +586,26 @@ func parseGroup(grp string) interface{} {
 	}
 	// If the group couldn't be parsed, give up and make it an OtherRequirement
 	utils.VPrintf("'%s' -> parser.OtherRequirement", grp)
-	return *schema.NewOtherRequirement(ungroupText(grp), "")
+	return *schema.NewOtherRequirement(p.ungroupText(grp), "")
 }
 
 // Outermost function for parsing a chunk of requisite text (potentially containing multiple nested text groups)
-func parseChunk(chunk string) interface{} {
+func (p *Parser) parseChunk(chunk string) interface{} {
 	utils.VPrintf("\nPARSING CHUNK: '%s'", chunk)
 	// Extract parenthesized groups from chunk text
 	parseText, parseGroups := groupParens(chunk)
 	// Initialize the requisite list and group list
-	requisiteList = make([]interface{}, 0, len(parseGroups))
-	groupList = parseGroups
+	p.requisiteList = make([]interface{}, 0, len(parseGroups))
+	p.groupList = parseGroups
 	// Begin recursive group parsing -- order is bottom-up
 	for _, grp := range parseGroups {
-		parsedReq := parseGroup(grp)
+		parsedReq := p.parseGroup(grp)
 		// Only append requisite to stack if it isn't marked as throwaway
 		if !reqIsThrowaway(parsedReq) {
-			requisiteList = append(requisiteList, parsedReq)
+			p.requisiteList = append(p.requisiteList, parsedReq)
 		}
 	}
-	finalGroup := parseGroup(parseText)
+	finalGroup := p.parseGroup(parseText)
 	return finalGroup
 }
 
@@ -562,11 +645,11 @@ func groupParens(text string) (string, []string) {
 }
 
 // Function for replacing all group references (groups referenced via group tags) with their actual text
-func ungroupText(text string) string {
+func (p *Parser) ungroupText(text string) string {
 	text = utils.TrimWhitespace(text)
-	for groupNum := len(groupList) - 1; groupNum >= 0; groupNum-- {
+	for groupNum := len(p.groupList) - 1; groupNum >= 0; groupNum-- {
 		subText := fmt.Sprintf("@%d", groupNum)
-		replacementText := fmt.Sprintf("(%s)", groupList[groupNum])
+		replacementText := fmt.Sprintf("(%s)", p.groupList[groupNum])
 		text = strings.Replace(text, subText, replacementText, -1)
 	}
 	return text
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSharedRequisiteChunks(t *testing.T) {
+	p := NewParser()
+	p.reset()
+
+	// The honors section is restricted, but the other section lists no enrollment reqs at all
+	honorsReqs := "Restricted to honors students."
+	course := &schema.Course{Id: primitive.NewObjectID(), Description: "Prerequisite: CS 1325."}
+	p.courseReqTexts[course.Id] = []string{honorsReqs, ""}
+
+	shared := p.sharedRequisiteChunks(course)
+	if !reflect.DeepEqual(*shared, requisiteChunks{}) {
+		t.Errorf("got shared chunks %+v, want none", *shared)
+	}
+
+	// Only the honors section has the restriction
+	honors := &schema.Section{Id: primitive.NewObjectID()}
+	p.getSectionReqParser(honors, course, honorsReqs)()
+	attributes, ok := honors.Attributes.(*SectionAttributes)
+	if !ok || attributes.Requisites == nil || attributes.Requisites.Restrictions == nil {
+		t.Fatalf("got section attributes %+v, want the honors section's restriction", honors.Attributes)
+	}
+}
+
+func TestSharedRequisiteChunksFallBackToDescription(t *testing.T) {
+	p := NewParser()
+	p.reset()
+
+	course := &schema.Course{Id: primitive.NewObjectID(), Description: "Prerequisite: CS 1325."}
+	p.courseReqTexts[course.Id] = []string{"", ""}
+
+	shared := p.sharedRequisiteChunks(course)
+	if want := []string{"CS 1325"}; !reflect.DeepEqual(shared.reqs[1], want) {
+		t.Errorf("got prerequisite chunks %v, want %v from the description", shared.reqs[1], want)
+	}
+}
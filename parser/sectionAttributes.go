@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Extra section data that schema.Section has no fields for, stored in the section's Attributes
+type SectionAttributes struct {
+	Enrollment   *Enrollment   `bson:"enrollment,omitempty" json:"enrollment,omitempty"`
+	MeetingTimes []MeetingTime `bson:"meeting_times,omitempty" json:"meeting_times,omitempty"`
+	// Sections of other courses that this section is cross-listed with
+	CrossListed []primitive.ObjectID `bson:"cross_listed,omitempty" json:"cross_listed,omitempty"`
+	// Requisites and restrictions specific to this section, beyond its course's
+	Requisites *SectionRequisites `bson:"requisites,omitempty" json:"requisites,omitempty"`
+	// Every grade in the section's grade data, including the credit, pass/fail, and incomplete grades Grade_distribution leaves out
+	Grades *GradeDistribution `bson:"grades,omitempty" json:"grades,omitempty"`
+	// Set for sections made from grade data alone, for terms that weren't parsed from coursebook
+	GradeOnly bool `bson:"grade_only,omitempty" json:"grade_only,omitempty"`
+	// Set to "suppressed" or "coarsened" when the section had too few students to publish its grades as they are
+	GradeSuppression string `bson:"grade_suppression,omitempty" json:"grade_suppression,omitempty"`
+}
+
+// Requisites a section has on top of its course's, i.e. honors-only or major restrictions
+type SectionRequisites struct {
+	Co_or_pre_requisites *schema.CollectionRequirement `bson:"co_or_pre_requisites,omitempty" json:"co_or_pre_requisites,omitempty"`
+	Prerequisites        *schema.CollectionRequirement `bson:"prerequisites,omitempty" json:"prerequisites,omitempty"`
+	Corequisites         *schema.CollectionRequirement `bson:"corequisites,omitempty" json:"corequisites,omitempty"`
+	// Anything else the section's requisite text requires, i.e. "Honors students only"
+	Restrictions *schema.CollectionRequirement `bson:"restrictions,omitempty" json:"restrictions,omitempty"`
+}
+
+// Enrollment statuses
+const (
+	STATUS_OPEN      = "open"
+	STATUS_CLOSED    = "closed"
+	STATUS_CANCELLED = "cancelled"
+)
+
+// Enrollment numbers for a section at the time it was scraped; anything coursebook didn't list is left unset
+type Enrollment struct {
+	Status   string `bson:"status,omitempty" json:"status,omitempty"`
+	Enrolled *int   `bson:"enrolled,omitempty" json:"enrolled,omitempty"`
+	Capacity *int   `bson:"capacity,omitempty" json:"capacity,omitempty"`
+	Waitlist *int   `bson:"waitlist,omitempty" json:"waitlist,omitempty"`
+}
+
+// Machine-readable times for one of the section's meetings; meetings without set times (i.e. TBA) have none
+type MeetingTime struct {
+	// Index of the meeting in the section's meetings
+	Meeting int `bson:"meeting" json:"meeting"`
+	// Minutes since midnight
+	Start_minutes    int `bson:"start_minutes" json:"start_minutes"`
+	End_minutes      int `bson:"end_minutes" json:"end_minutes"`
+	Duration_minutes int `bson:"duration_minutes" json:"duration_minutes"`
+	// ISO 8601 weekday numbers, where Monday is 1 and Sunday is 7
+	Weekdays []int `bson:"weekdays" json:"weekdays"`
+}
+
+// Gets the section's attributes, creating them if the section doesn't have any yet
+func getSectionAttributes(section *schema.Section) *SectionAttributes {
+	attributes, ok := section.Attributes.(*SectionAttributes)
+	if !ok {
+		attributes = &SectionAttributes{}
+		section.Attributes = attributes
+	}
+	return attributes
+}
+
+// Reports whether the section was cancelled
+func isCancelled(section *schema.Section) bool {
+	attributes, ok := section.Attributes.(*SectionAttributes)
+	return ok && attributes.Enrollment != nil && attributes.Enrollment.Status == STATUS_CANCELLED
+}
+
+var firstNumberRegexp *regexp.Regexp = regexp.MustCompile(`[0-9]+`)
+
+// Gets the first value present in classInfo under any of the given labels
+func getClassInfo(classInfo map[string]string, labels ...string) (string, bool) {
+	for _, label := range labels {
+		if value, exists := classInfo[label]; exists {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Gets the first number in the value present in classInfo under any of the given labels, or nil if there's none
+func getClassInfoNumber(classInfo map[string]string, labels ...string) *int {
+	value, exists := getClassInfo(classInfo, labels...)
+	if !exists {
+		return nil
+	}
+	number, err := strconv.Atoi(firstNumberRegexp.FindString(value))
+	if err != nil {
+		return nil
+	}
+	return &number
+}
+
+// Parses the section's enrollment status, seats taken, capacity, and waitlist, returning nil if the page has none of them
+func getEnrollment(classInfo map[string]string) *Enrollment {
+	statusText, hasStatus := getClassInfo(classInfo, "Status:", "Enrollment Status:", "Class Status:")
+	enrolled := getClassInfoNumber(classInfo, "Enrolled Total:", "Enrolled:", "Seats Taken:")
+	available := getClassInfoNumber(classInfo, "Available Seats:", "Seats Available:")
+	capacity := getClassInfoNumber(classInfo, "Capacity:", "Class Capacity:", "Enrollment Capacity:")
+	waitlist := getClassInfoNumber(classInfo, "Waiting Total:", "Waitlist Total:", "Wait List Total:")
+
+	if !hasStatus && enrolled == nil && available == nil && capacity == nil {
+		return nil
+	}
+
+	// Capacity isn't always listed, but it's always the seats taken plus the seats left
+	if capacity == nil && enrolled != nil && available != nil {
+		seats := *enrolled + *available
+		capacity = &seats
+	}
+
+	return &Enrollment{
+		Status:   normalizeStatus(utils.TrimWhitespace(statusText), available != nil && *available == 0),
+		Enrolled: enrolled,
+		Capacity: capacity,
+		Waitlist: waitlist,
+	}
+}
+
+// Normalizes coursebook's status text to one of the enrollment statuses.
+// Sections without status text are only known to be closed if they have no seats left; otherwise their status is left empty.
+func normalizeStatus(statusText string, full bool) string {
+	lowerStatus := strings.ToLower(statusText)
+	switch {
+	case strings.Contains(lowerStatus, "cancel"):
+		return STATUS_CANCELLED
+	case strings.Contains(lowerStatus, "closed") || strings.Contains(lowerStatus, "full"):
+		return STATUS_CLOSED
+	case strings.Contains(lowerStatus, "open"):
+		return STATUS_OPEN
+	case lowerStatus == "" && full:
+		return STATUS_CLOSED
+	default:
+		return lowerStatus
+	}
+}
@@ -1,37 +1,41 @@
 package parser
 
 import (
-	"fmt"
+	"log"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/UTDNebula/api-tools/utils"
 	"github.com/UTDNebula/nebula-api/api/schema"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var sectionPrefixRegexp *regexp.Regexp = utils.Regexpf(`^(?i)%s\.(%s)`, utils.R_SUBJ_COURSE, utils.R_SECTION_CODE)
 var coreRegexp *regexp.Regexp = regexp.MustCompile(`[0-9]{3}`)
 var personRegexp *regexp.Regexp = regexp.MustCompile(`(.+)・(.+)・(.+)`)
 
-func parseSection(courseRef *schema.Course, classNum string, syllabusURI string, session schema.AcademicSession, rowInfo map[string]string, classInfo map[string]string) {
+func (p *Parser) parseSection(courseRef *schema.Course, classNum string, syllabusURI string, session schema.AcademicSession, rowInfo map[string]string, classInfo map[string]string) {
 	// Get subject prefix and course number by doing a regexp match on the section id
 	sectionId := classInfo["Class Section:"]
 	idMatches := sectionPrefixRegexp.FindStringSubmatch(sectionId)
 
 	section := &schema.Section{}
 
-	section.Id = primitive.NewObjectID()
 	section.Section_number = idMatches[1]
 	section.Course_reference = courseRef.Id
+	// IDs are derived from the section number, course, and term so they stay the same across runs
+	section.Id = utils.DeterministicID("section", section.Section_number, courseRef.Id.Hex(), session.Name)
 
-	//TODO: section requisites?
+	// The same section appearing twice would produce two sections with the same ID, so only keep the first
+	if _, exists := p.sections[section.Id]; exists {
+		log.Printf("WARN: Duplicate section %s found for term %s, skipping it!", sectionId, session.Name)
+		return
+	}
 
 	// Set academic session
 	section.Academic_session = session
 	// Add professors
-	section.Professors = parseProfessors(section.Id, rowInfo, classInfo)
+	section.Professors = p.parseProfessors(section.Id, rowInfo, classInfo)
 
 	// Get all TA/RA info
 	assistantText := rowInfo["TA/RA(s):"]
@@ -50,7 +54,27 @@ func parseSection(courseRef *schema.Course, classNum string, syllabusURI string,
 
 	section.Internal_class_number = classNum
 	section.Instruction_mode = classInfo["Instruction Mode:"]
-	section.Meetings = getMeetings(rowInfo, classInfo)
+	meetings, meetingErrs := getMeetings(rowInfo["Schedule:"], session, section.Instruction_mode)
+	section.Meetings = meetings
+	unparsedMeetings := len(p.report.UnparsedMeetings)
+	p.pageUndo = append(p.pageUndo, func() { p.report.UnparsedMeetings = p.report.UnparsedMeetings[:unparsedMeetings] })
+	for _, meetingErr := range meetingErrs {
+		log.Printf("WARN: Couldn't fully parse a meeting of %s: %s", sectionId, meetingErr.err)
+		p.report.UnparsedMeetings = append(p.report.UnparsedMeetings, MeetingFailure{
+			Section: sectionId,
+			Text:    utils.TrimWhitespace(meetingErr.text),
+			Error:   meetingErr.err.Error(),
+		})
+	}
+
+	if meetingTimes := getMeetingTimes(meetings); meetingTimes != nil {
+		getSectionAttributes(section).MeetingTimes = meetingTimes
+	}
+
+	// Parse enrollment status and numbers (may or may not exist)
+	if enrollment := getEnrollment(classInfo); enrollment != nil {
+		getSectionAttributes(section).Enrollment = enrollment
+	}
 
 	// Parse core flags (may or may not exist)
 	coreText, hasCore := rowInfo["Core:"]
@@ -60,20 +84,42 @@ func parseSection(courseRef *schema.Course, classNum string, syllabusURI string,
 
 	section.Syllabus_uri = syllabusURI
 
-	semesterGrades, exists := GradeMap[session.Name]
+	// Remember every section's enrollment reqs, even if it has none, since the course's requisites are the ones all of its sections share
+	enrollmentReqs, hasEnrollmentReqs := rowInfo["Enrollment Reqs:"]
+	p.courseReqTexts[courseRef.Id] = append(p.courseReqTexts[courseRef.Id], enrollmentReqs)
+	p.pageUndo = append(p.pageUndo, func() {
+		p.courseReqTexts[courseRef.Id] = p.courseReqTexts[courseRef.Id][:len(p.courseReqTexts[courseRef.Id])-1]
+	})
+
+	// Get closure for parsing any section-specific requisites
+	if hasEnrollmentReqs {
+		p.sectionReqParsers[section.Id] = p.getSectionReqParser(section, courseRef, enrollmentReqs)
+		p.pageUndo = append(p.pageUndo, func() { delete(p.sectionReqParsers, section.Id) })
+	}
+
+	// Remember any explicit cross-lists, which are linked once every section has been parsed
+	if crossListKeys := getCrossListKeys(session.Name, rowInfo, classInfo); crossListKeys != nil {
+		p.crossListKeys[section.Id] = crossListKeys
+		p.pageUndo = append(p.pageUndo, func() { delete(p.crossListKeys, section.Id) })
+	}
+
+	semesterGrades, exists := p.gradeMap[session.Name]
 	if exists {
-		// We have to trim leading zeroes from the section number in order to match properly, since the grade data does not use leading zeroes
-		trimmedSectionNumber := strings.TrimLeft(section.Section_number, "0")
-		// Key into grademap should be uppercased like the grade data
-		gradeKey := strings.ToUpper(courseRef.Subject_prefix + courseRef.Course_number + trimmedSectionNumber)
-		sectionGrades, exists := semesterGrades[gradeKey]
+		sectionGrades, exists := semesterGrades[gradeKey(courseRef, section.Section_number)]
 		if exists {
-			section.Grade_distribution = sectionGrades
+			// The section's own grades are discarded along with it, but whether the grades were matched and how they were held back aren't
+			wasMatched, suppressedGrades := sectionGrades.matched, len(p.suppressedGrades)
+			p.pageUndo = append(p.pageUndo, func() {
+				sectionGrades.matched = wasMatched
+				p.suppressedGrades = p.suppressedGrades[:suppressedGrades]
+			})
+			sectionGrades.matched = true
+			p.attachGrades(section, sectionKey(courseRef.Subject_prefix, courseRef.Course_number, section.Section_number, session.Name), sectionGrades.distribution)
 		}
 	}
 
 	// Add new section to section map
-	Sections[section.Id] = section
+	p.sections[section.Id] = section
 
 	// Append new section to course's section listing
 	courseRef.Sections = append(courseRef.Sections, section.Id)
@@ -82,11 +128,16 @@ func parseSection(courseRef *schema.Course, classNum string, syllabusURI string,
 var termRegexp *regexp.Regexp = utils.Regexpf(`(?i)Term: (%s)`, utils.R_TERM_CODE)
 var datesRegexp *regexp.Regexp = utils.Regexpf(`(?:Start|End)s: (%s)`, utils.R_DATE_MDY)
 
-func getAcademicSession(rowInfo map[string]string) schema.AcademicSession {
+func getAcademicSession(rowInfo map[string]string) (schema.AcademicSession, error) {
 	session := schema.AcademicSession{}
 	scheduleText := rowInfo["Schedule:"]
 
-	session.Name = termRegexp.FindStringSubmatch(scheduleText)[1]
+	termMatches := termRegexp.FindStringSubmatch(scheduleText)
+	if termMatches == nil {
+		return session, fieldErrorf("Schedule:", "couldn't find a term in '%s'", scheduleText)
+	}
+	// Term codes are matched case-insensitively, but catalog years are derived from the uppercase semester letter
+	session.Name = strings.ToUpper(termMatches[1])
 	dateMatches := datesRegexp.FindAllStringSubmatch(scheduleText, -1)
 
 	datesFound := len(dateMatches)
@@ -94,61 +145,20 @@ func getAcademicSession(rowInfo map[string]string) schema.AcademicSession {
 	case datesFound == 1:
 		startDate, err := time.ParseInLocation("January 2, 2006", dateMatches[0][1], timeLocation)
 		if err != nil {
-			panic(err)
+			return session, &FieldError{"Schedule:", err}
 		}
 		session.Start_date = startDate
 	case datesFound == 2:
 		startDate, err := time.ParseInLocation("January 2, 2006", dateMatches[0][1], timeLocation)
 		if err != nil {
-			panic(err)
+			return session, &FieldError{"Schedule:", err}
 		}
 		endDate, err := time.ParseInLocation("January 2, 2006", dateMatches[1][1], timeLocation)
 		if err != nil {
-			panic(err)
+			return session, &FieldError{"Schedule:", err}
 		}
 		session.Start_date = startDate
 		session.End_date = endDate
 	}
-	return session
-}
-
-var meetingsRegexp *regexp.Regexp = utils.Regexpf(`(%s)-(%s)\W+((?:%s(?:, )?)+)\W+(%s)-(%s)(?:\W+(?:(\S+)\s+(\S+)))`, utils.R_DATE_MDY, utils.R_DATE_MDY, utils.R_WEEKDAY, utils.R_TIME_AM_PM, utils.R_TIME_AM_PM)
-
-func getMeetings(rowInfo map[string]string, classInfo map[string]string) []schema.Meeting {
-	scheduleText := rowInfo["Schedule:"]
-	meetingMatches := meetingsRegexp.FindAllStringSubmatch(scheduleText, -1)
-	var meetings []schema.Meeting = make([]schema.Meeting, 0, len(meetingMatches))
-	for _, match := range meetingMatches {
-		meeting := schema.Meeting{}
-
-		startDate, err := time.ParseInLocation("January 2, 2006", match[1], timeLocation)
-		if err != nil {
-			panic(err)
-		}
-		meeting.Start_date = startDate
-
-		endDate, err := time.ParseInLocation("January 2, 2006", match[2], timeLocation)
-		if err != nil {
-			panic(err)
-		}
-		meeting.End_date = endDate
-
-		meeting.Meeting_days = strings.Split(match[3], ", ")
-
-		// Don't parse time into time object, adds unnecessary extra data
-		meeting.Start_time = match[4]
-		meeting.End_time = match[5]
-
-		// Only add location data if it's available
-		if len(match) > 6 {
-			location := schema.Location{}
-			location.Building = match[6]
-			location.Room = match[7]
-			location.Map_uri = fmt.Sprintf("https://locator.utdallas.edu/%s_%s", location.Building, location.Room)
-			meeting.Location = location
-		}
-
-		meetings = append(meetings, meeting)
-	}
-	return meetings
+	return session, nil
 }
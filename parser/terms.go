@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Directory names that are term codes, as the coursebook scraper organizes its output, i.e. "23F"
+var termDirRegexp *regexp.Regexp = utils.Regexpf(`^%s$`, utils.R_TERM_CODE)
+
+// Only parses pages for the given terms, i.e. "23F"; all terms are parsed if none are given
+func WithTerms(terms ...string) Option {
+	return func(p *Parser) {
+		p.terms = make(map[string]bool, len(terms))
+		for _, term := range terms {
+			if term = strings.ToUpper(utils.TrimWhitespace(term)); term != "" {
+				p.terms[term] = true
+			}
+		}
+	}
+}
+
+// Writes each term's sections, along with the courses and professors they reference, to their own directory in addition to the combined output
+func WithPerTermOutput(perTermOutput bool) Option {
+	return func(p *Parser) {
+		p.perTermOutput = perTermOutput
+	}
+}
+
+// Drops paths that are in a directory for a term that isn't being parsed, so they don't need to be read at all
+func (p *Parser) filterTermPaths(paths []string) []string {
+	if len(p.terms) == 0 {
+		return paths
+	}
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if p.includesTermPath(path) {
+			kept = append(kept, path)
+		}
+	}
+	if len(kept) != len(paths) {
+		log.Printf("Skipping %d files in directories for other terms.", len(paths)-len(kept))
+	}
+	return kept
+}
+
+func (p *Parser) includesTermPath(path string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if termDirRegexp.MatchString(dir) && !p.terms[strings.ToUpper(dir)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether the page is for a term being parsed; pages without a readable term are left for the parser to report
+func (p *Parser) includesPage(page *sectionPage) bool {
+	if len(p.terms) == 0 {
+		return true
+	}
+	session, err := getAcademicSession(page.rowInfo)
+	return err != nil || p.terms[session.Name]
+}
+
+// Writes a directory for each term in outDir containing the term's sections and the courses and professors they reference
+func writeTermOutputs(outDir string, format string, result *Result) error {
+	coursesById := make(map[primitive.ObjectID]*schema.Course, len(result.Courses))
+	for _, course := range result.Courses {
+		coursesById[course.Id] = course
+	}
+	professorsById := make(map[primitive.ObjectID]*schema.Professor, len(result.Professors))
+	for _, professor := range result.Professors {
+		professorsById[professor.Id] = professor
+	}
+
+	termSections := make(map[string][]*schema.Section)
+	for _, section := range result.Sections {
+		termSections[section.Academic_session.Name] = append(termSections[section.Academic_session.Name], section)
+	}
+
+	terms := utils.GetMapKeys(termSections)
+	sort.Strings(terms)
+	for _, term := range terms {
+		sections := termSections[term]
+		inTerm := make(map[primitive.ObjectID]bool, len(sections))
+		for _, section := range sections {
+			inTerm[section.Id] = true
+		}
+		// Courses and professors are copied so they only list the term's sections, which is all that's uploaded alongside them
+		var courses []*schema.Course
+		var professors []*schema.Professor
+		seen := make(map[primitive.ObjectID]bool)
+		for _, section := range sections {
+			if course, exists := coursesById[section.Course_reference]; exists && !seen[course.Id] {
+				seen[course.Id] = true
+				termCourse := *course
+				termCourse.Sections = filterSectionIDs(course.Sections, inTerm)
+				courses = append(courses, &termCourse)
+			}
+			for _, profId := range section.Professors {
+				if professor, exists := professorsById[profId]; exists && !seen[profId] {
+					seen[profId] = true
+					termProfessor := *professor
+					termProfessor.Sections = filterSectionIDs(professor.Sections, inTerm)
+					professors = append(professors, &termProfessor)
+				}
+			}
+		}
+
+		termDir := fmt.Sprintf("%s/terms/%s", outDir, term)
+		if err := os.MkdirAll(termDir, 0777); err != nil {
+			return err
+		}
+		if err := writeResult(termDir, format, courses, sections, professors); err != nil {
+			return err
+		}
+		log.Printf("Wrote %d sections for term %s to %s.", len(sections), term, termDir)
+	}
+	return nil
+}
+
+// Gets the section IDs that are in the given set
+func filterSectionIDs(sectionIds []primitive.ObjectID, kept map[primitive.ObjectID]bool) []primitive.ObjectID {
+	filtered := make([]primitive.ObjectID, 0, len(sectionIds))
+	for _, sectionId := range sectionIds {
+		if kept[sectionId] {
+			filtered = append(filtered, sectionId)
+		}
+	}
+	return filtered
+}
@@ -1,26 +1,78 @@
 package parser
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func validate() {
-	// Set up deferred handler for panics to display validation fails
+// Flags cancelled sections, removing them (and any references to them, and professors left without sections) if the parser is set to drop them
+func (p *Parser) checkCancelledSections() {
+	var cancelled []*schema.Section
+	for _, section := range p.sections {
+		if isCancelled(section) {
+			cancelled = append(cancelled, section)
+		}
+	}
+	if len(cancelled) == 0 {
+		return
+	}
+	if !p.dropCancelled {
+		log.Printf("Found %d cancelled sections. They'll be kept, but flagged with a cancelled enrollment status.", len(cancelled))
+		return
+	}
+
+	log.Printf("Dropping %d cancelled sections...", len(cancelled))
+	for _, section := range cancelled {
+		delete(p.sections, section.Id)
+		delete(p.crossListKeys, section.Id)
+		if course, exists := p.courses[p.courseIDMap[section.Course_reference]]; exists {
+			course.Sections = removeID(course.Sections, section.Id)
+		}
+		for _, profId := range section.Professors {
+			professorKey := p.professorIDMap[profId]
+			if prof, exists := p.professors[professorKey]; exists {
+				prof.Sections = removeID(prof.Sections, section.Id)
+				// Professors are only known from the sections they teach, so drop those who only taught cancelled sections
+				if len(prof.Sections) == 0 {
+					delete(p.professors, professorKey)
+					delete(p.professorIDMap, profId)
+				}
+			}
+		}
+	}
+}
+
+// Removes all occurrences of id from ids
+func removeID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	kept := make([]primitive.ObjectID, 0, len(ids))
+	for _, existingId := range ids {
+		if existingId != id {
+			kept = append(kept, existingId)
+		}
+	}
+	return kept
+}
+
+// Validates the parsed data, returning an error describing the first validation failure found
+func (p *Parser) validate() (err error) {
+	// Set up deferred handler for panics to report validation fails
 	defer func() {
-		if err := recover(); err != nil {
-			log.Printf("VALIDATION FAILED: %s", err)
+		if failure := recover(); failure != nil {
+			err = fmt.Errorf("%v", failure)
 		}
 	}()
 
 	log.Printf("\nValidating courses...")
-	courseKeys := utils.GetMapKeys(Courses)
+	courseKeys := utils.GetMapKeys(p.courses)
 	for i := 0; i < len(courseKeys)-1; i++ {
-		course1 := Courses[courseKeys[i]]
+		course1 := p.courses[courseKeys[i]]
 		// Check for duplicate courses by comparing course_number, subject_prefix, and catalog_year as a compound key
 		for j := i + 1; j < len(courseKeys); j++ {
-			course2 := Courses[courseKeys[j]]
+			course2 := p.courses[courseKeys[j]]
 			if course2.Catalog_year == course1.Catalog_year && course2.Course_number == course1.Course_number && course2.Subject_prefix == course1.Subject_prefix {
 				log.Printf("Duplicate course found for %s%s!", course1.Subject_prefix, course1.Course_number)
 				log.Printf("Course 1: %v\n\nCourse 2: %v", course1, course2)
@@ -29,7 +81,7 @@ func validate() {
 		}
 		// Make sure course isn't referencing any nonexistent sections, and that course-section references are consistent both ways
 		for _, sectionId := range course1.Sections {
-			section, exists := Sections[sectionId]
+			section, exists := p.sections[sectionId]
 			if !exists {
 				log.Printf("Nonexistent section reference found for %s%s!", course1.Subject_prefix, course1.Course_number)
 				log.Printf("Referenced section ID: %s\nCourse ID: %s", sectionId, course1.Id)
@@ -46,12 +98,12 @@ func validate() {
 	log.Print("No invalid courses!")
 
 	log.Print("Validating sections...")
-	sectionKeys := utils.GetMapKeys(Sections)
+	sectionKeys := utils.GetMapKeys(p.sections)
 	for i := 0; i < len(sectionKeys)-1; i++ {
-		section1 := Sections[sectionKeys[i]]
+		section1 := p.sections[sectionKeys[i]]
 		// Check for duplicate sections by comparing section_number, course_reference, and academic_session as a compound key
 		for j := i + 1; j < len(sectionKeys); j++ {
-			section2 := Sections[sectionKeys[j]]
+			section2 := p.sections[sectionKeys[j]]
 			if section2.Section_number == section1.Section_number &&
 				section2.Course_reference == section1.Course_reference &&
 				section2.Academic_session == section1.Academic_session {
@@ -62,14 +114,14 @@ func validate() {
 		}
 		// Make sure section isn't referencing any nonexistent professors, and that section-professor references are consistent both ways
 		for _, profId := range section1.Professors {
-			professorKey, exists := ProfessorIDMap[profId]
+			professorKey, exists := p.professorIDMap[profId]
 			if !exists {
 				log.Printf("Nonexistent professor reference found for section ID %s!", section1.Id)
 				log.Printf("Referenced professor ID: %s", profId)
 				log.Panic("Sections failed to validate!")
 			}
 			profRefsSection := false
-			for _, profSection := range Professors[professorKey].Sections {
+			for _, profSection := range p.professors[professorKey].Sections {
 				if profSection == section1.Id {
 					profRefsSection = true
 					break
@@ -82,7 +134,7 @@ func validate() {
 			}
 		}
 		// Make sure section isn't referencing a nonexistant course
-		_, exists := CourseIDMap[section1.Course_reference]
+		_, exists := p.courseIDMap[section1.Course_reference]
 		if !exists {
 			log.Printf("Nonexistent course reference found for section ID %s!", section1.Id)
 			log.Printf("Referenced course ID: %s", section1.Course_reference)
@@ -90,15 +142,30 @@ func validate() {
 		}
 	}
 	sectionKeys = nil
+	// Make sure every meeting with set times ends after it starts
+	for _, section := range p.sections {
+		attributes, ok := section.Attributes.(*SectionAttributes)
+		if !ok {
+			continue
+		}
+		for _, meetingTime := range attributes.MeetingTimes {
+			if meetingTime.Start_minutes >= meetingTime.End_minutes {
+				meeting := section.Meetings[meetingTime.Meeting]
+				log.Printf("Meeting ending before it starts found for section ID %s!", section.Id)
+				log.Printf("Start time: %s\nEnd time: %s", meeting.Start_time, meeting.End_time)
+				log.Panic("Sections failed to validate!")
+			}
+		}
+	}
 	log.Printf("No invalid sections!")
 
 	log.Printf("Validating professors...")
-	profKeys := utils.GetMapKeys(Professors)
+	profKeys := utils.GetMapKeys(p.professors)
 	// Check for duplicate professors by comparing first_name, last_name, and sections as a compound key
 	for i := 0; i < len(profKeys)-1; i++ {
-		prof1 := Professors[profKeys[i]]
+		prof1 := p.professors[profKeys[i]]
 		for j := i + 1; j < len(profKeys); j++ {
-			prof2 := Professors[profKeys[j]]
+			prof2 := p.professors[profKeys[j]]
 			if prof2.First_name == prof1.First_name &&
 				prof2.Last_name == prof1.Last_name &&
 				prof2.Profile_uri == prof1.Profile_uri {
@@ -109,4 +176,5 @@ func validate() {
 		}
 	}
 	log.Printf("No invalid professors!")
+	return nil
 }
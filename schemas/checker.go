@@ -0,0 +1,217 @@
+package schemas
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A place where a document doesn't match its schema
+type Violation struct {
+	// Line of the document in an NDJSON file, or 0 for JSON files
+	Line int
+	// JSON pointer to the offending value within the document
+	Pointer string
+	Message string
+}
+
+func (v Violation) String() string {
+	// The document itself has an empty pointer, which reads poorly in a report
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "(document)"
+	}
+	if v.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", v.Line, pointer, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", pointer, v.Message)
+}
+
+// Checks decoded JSON documents against a schema, supporting the keywords the generator writes
+type checker struct {
+	root     Schema
+	patterns map[string]*regexp.Regexp
+}
+
+func newChecker(root Schema) *checker {
+	return &checker{root: root, patterns: make(map[string]*regexp.Regexp)}
+}
+
+// Gets every violation in value, which was decoded by encoding/json; pointer is where value sits
+func (c *checker) check(schema Schema, value interface{}, pointer string) []Violation {
+	if ref, isRef := schema["$ref"].(string); isRef {
+		return c.check(c.resolve(ref), value, pointer)
+	}
+
+	if anyOf, hasAnyOf := schema["anyOf"].([]interface{}); hasAnyOf {
+		var closest []Violation
+		for _, option := range anyOf {
+			violations := c.check(asSchema(option), value, pointer)
+			if len(violations) == 0 {
+				return nil
+			}
+			// Report against the option that got the furthest, rather than every option
+			if closest == nil || len(violations) < len(closest) {
+				closest = violations
+			}
+		}
+		return closest
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesType(types, value) {
+		return []Violation{{Pointer: pointer, Message: fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), jsonType(value))}}
+	}
+
+	var violations []Violation
+	switch value := value.(type) {
+	case string:
+		if pattern, hasPattern := schema["pattern"].(string); hasPattern && !c.pattern(pattern).MatchString(value) {
+			violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("%q doesn't match %s", value, pattern)})
+		}
+		if format, _ := schema["format"].(string); format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("%q isn't a date-time", value)})
+			}
+		}
+	case []interface{}:
+		if items, hasItems := schema["items"]; hasItems {
+			for i, item := range value {
+				violations = append(violations, c.check(asSchema(items), item, fmt.Sprintf("%s/%d", pointer, i))...)
+			}
+		}
+	case map[string]interface{}:
+		violations = append(violations, c.checkObject(schema, value, pointer)...)
+	}
+	return violations
+}
+
+func (c *checker) checkObject(schema Schema, object map[string]interface{}, pointer string) []Violation {
+	var violations []Violation
+	properties := asSchema(schema["properties"])
+	if required, hasRequired := schema["required"].([]string); hasRequired {
+		for _, name := range required {
+			if _, exists := object[name]; !exists {
+				violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("missing required property %q", name)})
+			}
+		}
+	} else if required, hasRequired := schema["required"].([]interface{}); hasRequired {
+		for _, name := range required {
+			if _, exists := object[name.(string)]; !exists {
+				violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf("missing required property %q", name)})
+			}
+		}
+	}
+
+	if propertyNames, hasPropertyNames := schema["propertyNames"]; hasPropertyNames {
+		for name := range object {
+			violations = append(violations, c.check(asSchema(propertyNames), name, propertyPointer(pointer, name))...)
+		}
+	}
+
+	// Go through the properties in order so the report is the same from run to run
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if property, exists := properties[name]; exists {
+			violations = append(violations, c.check(asSchema(property), object[name], propertyPointer(pointer, name))...)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, Violation{Pointer: propertyPointer(pointer, name), Message: "unexpected property"})
+			}
+		case nil:
+		default:
+			violations = append(violations, c.check(asSchema(additional), object[name], propertyPointer(pointer, name))...)
+		}
+	}
+	return violations
+}
+
+// Looks up a reference to a definition in the root schema, i.e. "#/$defs/Course"
+func (c *checker) resolve(ref string) Schema {
+	name, isLocal := strings.CutPrefix(ref, "#/$defs/")
+	if def, exists := asSchema(c.root["$defs"])[name]; isLocal && exists {
+		return asSchema(def)
+	}
+	panic(fmt.Errorf("can't resolve schema reference %s", ref))
+}
+
+func (c *checker) pattern(pattern string) *regexp.Regexp {
+	if compiled, exists := c.patterns[pattern]; exists {
+		return compiled
+	}
+	compiled := regexp.MustCompile(pattern)
+	c.patterns[pattern] = compiled
+	return compiled
+}
+
+// Converts a schema or boolean schema, whether built by the generator or decoded from a file, to a Schema
+func asSchema(value interface{}) Schema {
+	switch value := value.(type) {
+	case Schema:
+		return value
+	case map[string]interface{}:
+		return Schema(value)
+	default:
+		return Schema{}
+	}
+}
+
+func schemaTypes(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		types := make([]string, 0, len(value))
+		for _, schemaType := range value {
+			types = append(types, schemaType.(string))
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+func matchesType(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, schemaType := range types {
+		if schemaType == actual || (schemaType == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the JSON Schema type of a value decoded by encoding/json
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Appends a property name to a JSON pointer, escaping it as RFC 6901 requires
+func propertyPointer(pointer string, name string) string {
+	return pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
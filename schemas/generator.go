@@ -0,0 +1,141 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A JSON Schema, kept as a plain map so it marshals exactly as written
+type Schema map[string]interface{}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	objectIdType   = reflect.TypeOf(primitive.ObjectID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Builds schemas from Go types, collecting every named struct into shared definitions
+type generator struct {
+	defs Schema
+	// The type each definition was made from, to catch two types with the same name
+	defTypes map[string]reflect.Type
+	// What the interface{} Attributes field of a struct actually holds, by struct
+	attributes map[reflect.Type]reflect.Type
+}
+
+func newGenerator() *generator {
+	return &generator{
+		defs:       Schema{},
+		defTypes:   make(map[string]reflect.Type),
+		attributes: make(map[reflect.Type]reflect.Type),
+	}
+}
+
+// Gets the schema for a value of type t as encoding/json would write it
+func (g *generator) schemaFor(t reflect.Type) Schema {
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case objectIdType:
+		return Schema{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	case rawMessageType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaFor(t.Elem()))
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice:
+		// Nil slices are written as null
+		return nullable(Schema{"type": "array", "items": g.schemaFor(t.Elem())})
+	case reflect.Array:
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return nullable(Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem())})
+	case reflect.Interface:
+		return Schema{}
+	case reflect.Struct:
+		return Schema{"$ref": "#/$defs/" + g.define(t)}
+	default:
+		panic(fmt.Errorf("can't make a schema for %s", t))
+	}
+}
+
+// Adds a definition for the struct type t if there isn't one yet, returning its name
+func (g *generator) define(t reflect.Type) string {
+	name := t.Name()
+	if existing, exists := g.defTypes[name]; exists && existing != t {
+		name = fmt.Sprintf("%s.%s", t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:], name)
+	}
+	if _, exists := g.defTypes[name]; exists {
+		return name
+	}
+	g.defTypes[name] = t
+	// Hold the name before filling in the properties so recursive types refer back to it
+	def := Schema{"type": "object"}
+	g.defs[name] = def
+
+	properties := Schema{}
+	required := []string{}
+	g.addFields(t, t, properties, &required)
+	def["properties"] = properties
+	def["required"] = required
+	def["additionalProperties"] = false
+	return name
+}
+
+// Adds the fields of struct type t to properties, flattening embedded structs as encoding/json does
+func (g *generator) addFields(owner reflect.Type, t reflect.Type, properties Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(owner, field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldType := field.Type
+		if attributesType, exists := g.attributes[owner]; exists && field.Name == "Attributes" && fieldType.Kind() == reflect.Interface {
+			fieldType = reflect.PointerTo(attributesType)
+		}
+		properties[name] = g.schemaFor(fieldType)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// Allows null in addition to whatever the schema allows
+func nullable(schema Schema) Schema {
+	if ref, isRef := schema["$ref"]; isRef {
+		return Schema{"anyOf": []interface{}{Schema{"$ref": ref}, Schema{"type": "null"}}}
+	}
+	if schemaType, hasType := schema["type"].(string); hasType {
+		schema["type"] = []interface{}{schemaType, "null"}
+	}
+	return schema
+}
@@ -0,0 +1,193 @@
+/*
+	This file is responsible for the JSON Schemas describing each data file the tool writes,
+	and for checking data files against them.
+*/
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/UTDNebula/api-tools/linker"
+	"github.com/UTDNebula/api-tools/parser"
+	"github.com/UTDNebula/api-tools/scrapers"
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+const JSON_SCHEMA_DRAFT = "https://json-schema.org/draft/2020-12/schema"
+
+// A data file the tool writes, named as it's written i.e. "courses" for courses.json
+type dataFile struct {
+	name string
+	// The type of each document in the file
+	document reflect.Type
+	// What the document's interface{} Attributes field holds, if it has one
+	attributes reflect.Type
+	// Set for files that aren't written as a JSON array, to build the JSON file's schema from the document's
+	jsonFile func(document Schema) Schema
+}
+
+var dataFiles = []dataFile{
+	{name: "courses", document: reflect.TypeOf(schema.Course{}), attributes: reflect.TypeOf(parser.CourseAttributes{})},
+	{name: "sections", document: reflect.TypeOf(schema.Section{}), attributes: reflect.TypeOf(parser.SectionAttributes{})},
+	{name: "professors", document: reflect.TypeOf(schema.Professor{})},
+	{name: "grade_stats", document: reflect.TypeOf(parser.GradeStats{})},
+	{name: "profiles", document: reflect.TypeOf(schema.Professor{})},
+	{name: "organizations", document: reflect.TypeOf(schema.Organization{})},
+	{name: "events", document: reflect.TypeOf(schema.Event{})},
+	{name: "event_locations", document: reflect.TypeOf(scrapers.EventLocation{})},
+	{name: "event_organizations", document: reflect.TypeOf(linker.EventOrganization{})},
+	{name: "reservations", document: reflect.TypeOf(scrapers.AstraDay{}), jsonFile: reservationsFile},
+}
+
+// The JSON reservations file is a single object of each day's reservations keyed by date, rather than an array of days
+func reservationsFile(document Schema) Schema {
+	return Schema{
+		"type":                 "object",
+		"propertyNames":        Schema{"type": "string", "pattern": `^\d{4}-\d{2}-\d{2}$`},
+		"additionalProperties": Schema{},
+	}
+}
+
+// Builds the schema for a whole file of the given format, or for one line of it for NDJSON
+func (file dataFile) schema(format string) Schema {
+	g := newGenerator()
+	if file.attributes != nil {
+		g.attributes[file.document] = file.attributes
+	}
+	document := g.schemaFor(file.document)
+
+	var root Schema
+	switch {
+	case format == utils.FORMAT_NDJSON:
+		root = document
+	case file.jsonFile != nil:
+		root = file.jsonFile(document)
+	default:
+		root = Schema{"type": "array", "items": document}
+	}
+	root["$schema"] = JSON_SCHEMA_DRAFT
+	root["title"] = utils.DataFilePath("", file.name, format)
+	root["$defs"] = g.defs
+	return root
+}
+
+// Writes the schemas for every data file to outDir: <name>.schema.json describes <name>.json, and <name>.ndjson.schema.json describes each line of <name>.ndjson
+func Generate(outDir string) {
+	if err := os.MkdirAll(outDir, 0777); err != nil {
+		panic(err)
+	}
+	for _, file := range dataFiles {
+		if err := utils.WriteJSON(filepath.Join(outDir, file.name+".schema.json"), file.schema(utils.FORMAT_JSON)); err != nil {
+			panic(err)
+		}
+		if err := utils.WriteJSON(filepath.Join(outDir, file.name+".ndjson.schema.json"), file.schema(utils.FORMAT_NDJSON)); err != nil {
+			panic(err)
+		}
+	}
+	log.Printf("Wrote schemas for %d data files to %s.", len(dataFiles), outDir)
+}
+
+// Checks every data file found in inDir and its subdirectories against its schema, logging each violation.
+// Exits with a non-zero status if any file doesn't match.
+func Check(inDir string) {
+	checkedFiles := 0
+	failedFiles := 0
+	err := filepath.WalkDir(inDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		file, format, isDataFile := findDataFile(entry.Name())
+		if !isDataFile {
+			return nil
+		}
+		violations, err := checkFile(path, file, format)
+		if err != nil {
+			return err
+		}
+		checkedFiles++
+		if len(violations) > 0 {
+			failedFiles++
+		}
+		for _, violation := range violations {
+			log.Printf("%s: %s", path, violation)
+		}
+		utils.VPrintf("Checked %s: %d violations", path, len(violations))
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if checkedFiles == 0 {
+		log.Fatalf("No data files found in %s!", inDir)
+	}
+	if failedFiles > 0 {
+		log.Fatalf("SCHEMA CHECK FAILED: %d of %d data files don't match their schemas.", failedFiles, checkedFiles)
+	}
+	log.Printf("All %d data files match their schemas.", checkedFiles)
+}
+
+// Finds the data file and format a file name belongs to, i.e. "courses.ndjson"
+func findDataFile(fileName string) (dataFile, string, bool) {
+	for _, format := range []string{utils.FORMAT_JSON, utils.FORMAT_NDJSON} {
+		name, hasExtension := strings.CutSuffix(fileName, "."+format)
+		if !hasExtension {
+			continue
+		}
+		for _, file := range dataFiles {
+			if file.name == name {
+				return file, format, true
+			}
+		}
+	}
+	return dataFile{}, "", false
+}
+
+// Gets every violation in the data file at path. Pointers in JSON arrays start with the document's index,
+// while violations in NDJSON files are given the document's line.
+func checkFile(path string, file dataFile, format string) ([]Violation, error) {
+	root := file.schema(format)
+	checker := newChecker(root)
+
+	// Files that aren't arrays are small, so they're checked whole
+	if format == utils.FORMAT_JSON && file.jsonFile != nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := json.Unmarshal(content, &value); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return checker.check(root, value, ""), nil
+	}
+
+	document := root
+	if format == utils.FORMAT_JSON {
+		document = asSchema(root["items"])
+	}
+	var violations []Violation
+	index := 0
+	err := utils.ReadDataFile(path, func(value interface{}) error {
+		for _, violation := range checker.check(document, value, "") {
+			if format == utils.FORMAT_JSON {
+				violation.Pointer = fmt.Sprintf("/%d%s", index, violation.Pointer)
+			} else {
+				violation.Line = index + 1
+			}
+			violations = append(violations, violation)
+		}
+		index++
+		return nil
+	})
+	return violations, err
+}
@@ -5,11 +5,13 @@
 package scrapers
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/UTDNebula/api-tools/utils"
@@ -19,7 +21,13 @@ import (
 
 var MAX_EVENTS_PER_DAY = 5000
 
-func ScrapeAstra(outDir string) {
+// Raw reservation data for a single day
+type AstraDay struct {
+	Date         string          `json:"date"`
+	Reservations json.RawMessage `json:"reservations"`
+}
+
+func ScrapeAstra(outDir string, format string) {
 
 	// Load env vars
 	if err := godotenv.Load(); err != nil {
@@ -35,8 +43,7 @@ func ScrapeAstra(outDir string) {
 		panic(err)
 	}
 
-	days := "{"       // String JSON for storing results by day
-	firstLoop := true // To avoid adding a comma to the JSON on the first loop
+	var days []AstraDay // Results by day
 
 	// Init http client
 	tr := &http.Transport{
@@ -83,7 +90,6 @@ func ScrapeAstra(outDir string) {
 			panic(err)
 		}
 		res.Body.Close()
-		stringBody := string(body)
 
 		// Check for no events
 		numEvents := fastjson.GetInt(body, "totalRecords")
@@ -100,24 +106,29 @@ func ScrapeAstra(outDir string) {
 		}
 
 		// Add to record
-		comma := ","
-		if firstLoop {
-			comma = ""
-			firstLoop = false
-		}
-		days = fmt.Sprintf("%s%s\"%s\":%s", days, comma, formattedDate, stringBody)
+		days = append(days, AstraDay{Date: formattedDate, Reservations: body})
 		date = date.Add(time.Hour * 24)
 	}
 
 	// Write event data to output file
-	days = fmt.Sprintf("%s}", days)
-	fptr, err := os.Create(fmt.Sprintf("%s/reservations.json", outDir))
-	if err != nil {
+	if err := writeAstraDays(outDir, format, days); err != nil {
 		panic(err)
 	}
-	_, err = fptr.Write([]byte(days))
-	if err != nil {
-		panic(err)
+}
+
+// Writes the reservations as a JSON object keyed by date, or as one line per day for NDJSON
+func writeAstraDays(outDir string, format string, days []AstraDay) error {
+	if format == utils.FORMAT_NDJSON {
+		return utils.WriteNDJSON(utils.DataFilePath(outDir, "reservations", format), days)
+	}
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i, day := range days {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		fmt.Fprintf(&builder, "\"%s\":%s", day.Date, day.Reservations)
 	}
-	fptr.Close()
+	builder.WriteString("}")
+	return os.WriteFile(utils.DataFilePath(outDir, "reservations", format), []byte(builder.String()), 0666)
 }
@@ -0,0 +1,424 @@
+/*
+	This file contains the code for ingesting events from the calendar's machine-readable feeds.
+
+	The campus calendar publishes both a paginated JSON API and an iCalendar feed, which are much cheaper and
+	more reliable to read than the rendered event pages. Both feeds can also be read from a saved file, which
+	is useful for testing against fixtures.
+*/
+
+package scrapers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UTDNebula/api-tools/utils"
+	"github.com/UTDNebula/nebula-api/api/schema"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const EVENTS_API_LINK string = "https://calendar.utdallas.edu/api/2/events"
+const EVENTS_ICS_LINK string = "https://calendar.utdallas.edu/calendar.ics"
+
+// Number of events to request per page of the JSON API (the API caps this at 100)
+const EVENTS_PER_PAGE = 100
+
+// Client for requesting the feeds, so a stalled request can't hang the scrape
+var feedClient = &http.Client{Timeout: time.Minute}
+
+// Shape of a response from the calendar's JSON API
+type eventsAPIResponse struct {
+	Events []struct {
+		Event eventsAPIEvent `json:"event"`
+	} `json:"events"`
+	Page struct {
+		Current int `json:"current"`
+		Size    int `json:"size"`
+		Total   int `json:"total"`
+	} `json:"page"`
+}
+
+type eventsAPIFilter struct {
+	Name string `json:"name"`
+}
+
+type eventsAPIEvent struct {
+	Id              int64  `json:"id"`
+	Title           string `json:"title"`
+	Url             string `json:"url"`
+	LocalistUrl     string `json:"localist_url"`
+	LocationName    string `json:"location_name"`
+	RoomNumber      string `json:"room_number"`
+	DescriptionText string `json:"description_text"`
+	EventInstances  []struct {
+		EventInstance struct {
+			Id     int64  `json:"id"`
+			Start  string `json:"start"`
+			End    string `json:"end"`
+			AllDay bool   `json:"all_day"`
+		} `json:"event_instance"`
+	} `json:"event_instances"`
+	Filters struct {
+		EventTypes          []eventsAPIFilter `json:"event_types"`
+		EventTargetAudience []eventsAPIFilter `json:"event_target_audience"`
+		EventTopic          []eventsAPIFilter `json:"event_topic"`
+	} `json:"filters"`
+	Tags         []string          `json:"tags"`
+	Departments  []eventsAPIFilter `json:"departments"`
+	CustomFields struct {
+		ContactName  string `json:"contact_information_name"`
+		ContactEmail string `json:"contact_information_email"`
+		ContactPhone string `json:"contact_information_phone"`
+	} `json:"custom_fields"`
+}
+
+// Reads all events in the window from the given feed source ("json" or "ics").
+// If feedPath is given, the feed is read from that URL or file instead of the live calendar.
+func scrapeEventFeed(source string, feedPath string, windowStart time.Time, windowEnd time.Time) ([]schema.Event, error) {
+	switch source {
+	case "json":
+		return scrapeEventsAPI(feedPath, windowStart, windowEnd)
+	case "ics":
+		feed, err := openFeed(feedPath, EVENTS_ICS_LINK)
+		if err != nil {
+			return nil, err
+		}
+		defer feed.Close()
+		events, err := parseEventsICS(feed)
+		if err != nil {
+			return nil, err
+		}
+		return filterEventWindow(events, windowStart, windowEnd), nil
+	default:
+		return nil, fmt.Errorf("unknown event feed source '%s'", source)
+	}
+}
+
+// Pages through the JSON API, or reads a single saved page if feedPath is given
+func scrapeEventsAPI(feedPath string, windowStart time.Time, windowEnd time.Time) ([]schema.Event, error) {
+	if feedPath != "" {
+		feed, err := openFeed(feedPath, "")
+		if err != nil {
+			return nil, err
+		}
+		defer feed.Close()
+		response, err := parseEventsAPIPage(feed)
+		if err != nil {
+			return nil, err
+		}
+		return filterEventWindow(eventsFromAPIResponses(response), windowStart, windowEnd), nil
+	}
+
+	var responses []*eventsAPIResponse
+	for pageNum := 1; ; pageNum++ {
+		pageURL := fmt.Sprintf("%s?start=%s&end=%s&pp=%d&page=%d", EVENTS_API_LINK, windowStart.Format("2006-01-02"), windowEnd.Format("2006-01-02"), EVENTS_PER_PAGE, pageNum)
+		utils.VPrintf("Reading event feed page %s", pageURL)
+		feed, err := openFeed(pageURL, "")
+		if err != nil {
+			return nil, err
+		}
+		response, err := parseEventsAPIPage(feed)
+		feed.Close()
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
+		if len(response.Events) == 0 || response.Page.Current >= response.Page.Total {
+			break
+		}
+	}
+	return filterEventWindow(eventsFromAPIResponses(responses...), windowStart, windowEnd), nil
+}
+
+// Opens the feed at the given URL or file path, falling back to defaultURL if feedPath is empty
+func openFeed(feedPath string, defaultURL string) (io.ReadCloser, error) {
+	if feedPath == "" {
+		feedPath = defaultURL
+	}
+	if !strings.HasPrefix(feedPath, "http://") && !strings.HasPrefix(feedPath, "https://") {
+		return os.Open(feedPath)
+	}
+	res, err := feedClient.Get(feedPath)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != 200 {
+		res.Body.Close()
+		return nil, fmt.Errorf("event feed %s returned status %s", feedPath, res.Status)
+	}
+	return res.Body, nil
+}
+
+// Decodes a single page of the JSON API
+func parseEventsAPIPage(r io.Reader) (*eventsAPIResponse, error) {
+	var response eventsAPIResponse
+	if err := json.NewDecoder(r).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode event feed: %w", err)
+	}
+	return &response, nil
+}
+
+// Builds events from JSON API responses, making one event per event instance.
+// Instances repeated across pages are only included once.
+func eventsFromAPIResponses(responses ...*eventsAPIResponse) []schema.Event {
+	events := []schema.Event{}
+	seenInstances := make(map[int64]bool)
+	for _, response := range responses {
+		for _, wrapper := range response.Events {
+			apiEvent := wrapper.Event
+			for _, instanceWrapper := range apiEvent.EventInstances {
+				instance := instanceWrapper.EventInstance
+				if seenInstances[instance.Id] {
+					continue
+				}
+				seenInstances[instance.Id] = true
+
+				startTime, err := time.Parse(time.RFC3339, instance.Start)
+				if err != nil {
+					utils.VPrintf("Skipping instance %d of event %d with bad start time '%s'", instance.Id, apiEvent.Id, instance.Start)
+					continue
+				}
+				var endTime time.Time
+				if instance.End != "" {
+					endTime, _ = time.Parse(time.RFC3339, instance.End)
+				}
+
+				location := apiEvent.LocationName
+				if apiEvent.RoomNumber != "" {
+					location = utils.TrimWhitespace(location + " " + apiEvent.RoomNumber)
+				}
+
+				events = append(events, schema.Event{
+					Id:                 primitive.NewObjectID(),
+					Summary:            apiEvent.Title,
+					Location:           location,
+					StartTime:          startTime,
+					EndTime:            endTime,
+					Description:        apiEvent.DescriptionText,
+					EventType:          filterNames(apiEvent.Filters.EventTypes),
+					TargetAudience:     filterNames(apiEvent.Filters.EventTargetAudience),
+					Topic:              filterNames(apiEvent.Filters.EventTopic),
+					EventTags:          nonNilStrings(apiEvent.Tags),
+					EventWebsite:       apiEvent.Url,
+					Department:         filterNames(apiEvent.Departments),
+					ContactName:        apiEvent.CustomFields.ContactName,
+					ContactEmail:       apiEvent.CustomFields.ContactEmail,
+					ContactPhoneNumber: apiEvent.CustomFields.ContactPhone,
+				})
+			}
+		}
+	}
+	return events
+}
+
+func filterNames(filters []eventsAPIFilter) []string {
+	names := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		names = append(names, filter.Name)
+	}
+	return names
+}
+
+// Makes sure empty lists are written as [] rather than null, matching the DOM scraper's output
+func nonNilStrings(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// Parses the VEVENTs of an iCalendar feed into events.
+// Recurring instances are listed as separate VEVENTs in the feed, so each one becomes its own event.
+// Malformed VEVENTs are logged and skipped rather than failing the whole feed.
+func parseEventsICS(r io.Reader) ([]schema.Event, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []schema.Event{}
+	// Instances are keyed by UID and start time, since recurring instances share a UID
+	seenInstances := make(map[string]bool)
+	var props map[string]icsProperty
+	for lineNum, line := range lines {
+		switch line {
+		case "BEGIN:VEVENT":
+			props = make(map[string]icsProperty)
+			continue
+		case "END:VEVENT":
+			if props == nil {
+				log.Printf("Skipping END:VEVENT without BEGIN:VEVENT on line %d of the event feed", lineNum+1)
+				continue
+			}
+			event, err := eventFromICS(props)
+			if err != nil {
+				log.Printf("Skipping malformed event ending on line %d of the event feed: %v", lineNum+1, err)
+				props = nil
+				continue
+			}
+			instanceKey := props["UID"].Value + "|" + props["DTSTART"].Value
+			if !seenInstances[instanceKey] {
+				seenInstances[instanceKey] = true
+				events = append(events, event)
+			}
+			props = nil
+			continue
+		}
+		// Skip any properties outside of VEVENTs
+		if props == nil {
+			continue
+		}
+		prop, ok := parseICSProperty(line)
+		if !ok {
+			continue
+		}
+		// Categories may be split across multiple properties
+		if existing, exists := props[prop.Name]; exists && prop.Name == "CATEGORIES" {
+			prop.Value = existing.Value + "," + prop.Value
+		}
+		props[prop.Name] = prop
+	}
+	return events, nil
+}
+
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Reads the lines of an iCalendar feed, joining folded lines back together
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// Splits a content line of the form NAME;PARAM=VALUE:VALUE
+func parseICSProperty(line string) (icsProperty, bool) {
+	colonIndex := strings.Index(line, ":")
+	if colonIndex == -1 {
+		return icsProperty{}, false
+	}
+	nameAndParams := strings.Split(line[:colonIndex], ";")
+	prop := icsProperty{
+		Name:   strings.ToUpper(nameAndParams[0]),
+		Params: make(map[string]string),
+		Value:  line[colonIndex+1:],
+	}
+	for _, param := range nameAndParams[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if found {
+			prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+var icsTextReplacer = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeICSText(text string) string {
+	return icsTextReplacer.Replace(text)
+}
+
+// Parses a DATE or DATE-TIME value, honoring any TZID parameter
+func parseICSTime(prop icsProperty) (time.Time, error) {
+	location := calendarLocation
+	if tzid, hasTzid := prop.Params["TZID"]; hasTzid {
+		if tzLocation, err := time.LoadLocation(tzid); err == nil {
+			location = tzLocation
+		}
+	}
+	switch {
+	case prop.Params["VALUE"] == "DATE" || len(prop.Value) == 8:
+		return time.ParseInLocation("20060102", prop.Value, location)
+	case strings.HasSuffix(prop.Value, "Z"):
+		return time.Parse("20060102T150405Z", prop.Value)
+	default:
+		return time.ParseInLocation("20060102T150405", prop.Value, location)
+	}
+}
+
+func eventFromICS(props map[string]icsProperty) (schema.Event, error) {
+	startProp, hasStart := props["DTSTART"]
+	if !hasStart {
+		return schema.Event{}, errors.New("event is missing DTSTART")
+	}
+	startTime, err := parseICSTime(startProp)
+	if err != nil {
+		return schema.Event{}, err
+	}
+	var endTime time.Time
+	if endProp, hasEnd := props["DTEND"]; hasEnd {
+		endTime, err = parseICSTime(endProp)
+		if err != nil {
+			return schema.Event{}, err
+		}
+	}
+
+	eventTypes := []string{}
+	if categories, hasCategories := props["CATEGORIES"]; hasCategories {
+		for _, category := range strings.Split(categories.Value, ",") {
+			category = utils.TrimWhitespace(unescapeICSText(category))
+			if category != "" {
+				eventTypes = append(eventTypes, category)
+			}
+		}
+	}
+
+	event := schema.Event{
+		Id:             primitive.NewObjectID(),
+		Summary:        unescapeICSText(props["SUMMARY"].Value),
+		Location:       unescapeICSText(props["LOCATION"].Value),
+		StartTime:      startTime,
+		EndTime:        endTime,
+		Description:    unescapeICSText(props["DESCRIPTION"].Value),
+		EventType:      eventTypes,
+		TargetAudience: []string{},
+		Topic:          []string{},
+		EventTags:      []string{},
+		EventWebsite:   props["URL"].Value,
+		Department:     []string{},
+	}
+	// The organizer, if present, is the best contact information the feed has
+	if organizer, hasOrganizer := props["ORGANIZER"]; hasOrganizer {
+		event.ContactName = organizer.Params["CN"]
+		event.ContactEmail = strings.TrimPrefix(strings.TrimPrefix(organizer.Value, "mailto:"), "MAILTO:")
+	}
+	return event, nil
+}
+
+// Keeps only the events that start within the window, ordered by start time
+func filterEventWindow(events []schema.Event, windowStart time.Time, windowEnd time.Time) []schema.Event {
+	// The window's end date is inclusive
+	windowEnd = windowEnd.AddDate(0, 0, 1)
+	filtered := make([]schema.Event, 0, len(events))
+	for _, event := range events {
+		if !event.StartTime.Before(windowStart) && event.StartTime.Before(windowEnd) {
+			filtered = append(filtered, event)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].StartTime.Before(filtered[j].StartTime) })
+	return filtered
+}
@@ -0,0 +1,157 @@
+package scrapers
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	fixture, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fixture.Close() })
+	return fixture
+}
+
+func TestParseEventsAPIPage(t *testing.T) {
+	response, err := parseEventsAPIPage(openFixture(t, "events_api_page.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Events) != 3 || response.Page.Current != 1 || response.Page.Total != 1 {
+		t.Fatalf("got %d events on page %d of %d, want 3 events on page 1 of 1", len(response.Events), response.Page.Current, response.Page.Total)
+	}
+
+	events := eventsFromAPIResponses(response)
+	// The repeated instance 9001 and the instance with a bad start time are left out
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	first := events[0]
+	if first.Summary != "Robotics Club Info Session" || first.Location != "Engineering and Computer Science North 2.120" {
+		t.Errorf("got summary %q and location %q", first.Summary, first.Location)
+	}
+	wantStart := time.Date(2024, 9, 3, 23, 0, 0, 0, time.UTC)
+	wantEnd := time.Date(2024, 9, 4, 0, 30, 0, 0, time.UTC)
+	if !first.StartTime.Equal(wantStart) || !first.EndTime.Equal(wantEnd) {
+		t.Errorf("got times %s to %s, want %s to %s", first.StartTime, first.EndTime, wantStart, wantEnd)
+	}
+	if !reflect.DeepEqual(first.EventType, []string{"Meeting"}) || !reflect.DeepEqual(first.EventTags, []string{"robotics"}) {
+		t.Errorf("got event types %v and tags %v", first.EventType, first.EventTags)
+	}
+	if first.ContactName != "Jane Doe" || first.ContactEmail != "jane.doe@example.edu" || first.ContactPhoneNumber != "972-555-0100" {
+		t.Errorf("got contact %q %q %q", first.ContactName, first.ContactEmail, first.ContactPhoneNumber)
+	}
+
+	careerFair := events[2]
+	if careerFair.Location != "Student Union" || !careerFair.EndTime.IsZero() {
+		t.Errorf("got location %q and end time %s, want no room number or end time", careerFair.Location, careerFair.EndTime)
+	}
+	// Missing lists are written as [] rather than null
+	if careerFair.EventTags == nil || careerFair.EventType == nil || careerFair.Department == nil {
+		t.Errorf("got nil lists for an event without filters or tags")
+	}
+}
+
+func TestParseEventsAPIPageMalformed(t *testing.T) {
+	if _, err := parseEventsAPIPage(strings.NewReader(`{"events": [`)); err == nil {
+		t.Error("expected an error for a truncated page")
+	}
+}
+
+func TestParseEventsICS(t *testing.T) {
+	events, err := parseEventsICS(openFixture(t, "events.ics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second copy of event-3 has the same UID and start time, so it's left out
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+
+	lecture := events[0]
+	if lecture.Summary != "Guest Lecture, Part One" {
+		t.Errorf("got summary %q", lecture.Summary)
+	}
+	wantDescription := "A talk about distributed systems that goes on long enough that the feed folds it onto a second line.\nBring questions."
+	if lecture.Description != wantDescription {
+		t.Errorf("got description %q, want the folded lines joined: %q", lecture.Description, wantDescription)
+	}
+	// TZID times are read in their own time zone
+	wantStart := time.Date(2024, 9, 4, 17, 0, 0, 0, time.UTC)
+	if !lecture.StartTime.Equal(wantStart) || !lecture.EndTime.Equal(wantStart.Add(time.Hour)) {
+		t.Errorf("got times %s to %s, want %s to %s", lecture.StartTime, lecture.EndTime, wantStart, wantStart.Add(time.Hour))
+	}
+	if !reflect.DeepEqual(lecture.EventType, []string{"Lecture", "Academic", "Computer Science"}) {
+		t.Errorf("got categories %v", lecture.EventType)
+	}
+	if lecture.ContactName != "Dr. Ada Lovelace" || lecture.ContactEmail != "ada@example.edu" {
+		t.Errorf("got organizer %q %q", lecture.ContactName, lecture.ContactEmail)
+	}
+
+	// All-day events start at midnight on the calendar's time zone
+	dayOfService := events[1]
+	wantDay := time.Date(2024, 9, 6, 0, 0, 0, 0, calendarLocation)
+	if !dayOfService.StartTime.Equal(wantDay) || !dayOfService.EndTime.Equal(wantDay.AddDate(0, 0, 1)) {
+		t.Errorf("got all-day times %s to %s", dayOfService.StartTime, dayOfService.EndTime)
+	}
+
+	studySession := events[2]
+	if !studySession.StartTime.Equal(time.Date(2024, 9, 10, 23, 0, 0, 0, time.UTC)) {
+		t.Errorf("got UTC start time %s", studySession.StartTime)
+	}
+
+	// Floating times are read in the calendar's time zone
+	sendOff := events[3]
+	if !sendOff.StartTime.Equal(time.Date(2024, 8, 1, 17, 0, 0, 0, calendarLocation)) {
+		t.Errorf("got floating start time %s", sendOff.StartTime)
+	}
+}
+
+func TestParseEventsICSMalformed(t *testing.T) {
+	feed := "BEGIN:VCALENDAR\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:No start\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:Bad start\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:good\r\nSUMMARY:Good\r\nDTSTART:20240904T170000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	events, err := parseEventsICS(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Malformed events are skipped without losing the rest of the feed
+	if len(events) != 1 || events[0].Summary != "Good" {
+		t.Fatalf("got %+v, want only the well-formed event", events)
+	}
+}
+
+func TestFilterEventWindow(t *testing.T) {
+	at := func(day int, hour int) time.Time {
+		return time.Date(2024, 9, day, hour, 0, 0, 0, calendarLocation)
+	}
+	events := []schema.Event{
+		{Summary: "late", StartTime: at(10, 23)},
+		{Summary: "before", StartTime: at(2, 23)},
+		{Summary: "start", StartTime: at(3, 0)},
+		{Summary: "after", StartTime: at(11, 0)},
+		{Summary: "middle", StartTime: at(5, 12)},
+	}
+
+	filtered := filterEventWindow(events, at(3, 0), at(10, 0))
+	var summaries []string
+	for _, event := range filtered {
+		summaries = append(summaries, event.Summary)
+	}
+	// The window's start is inclusive, and so is the whole of its end date
+	want := []string{"start", "middle", "late"}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("got %v, want %v", summaries, want)
+	}
+}
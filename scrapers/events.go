@@ -6,12 +6,13 @@ package scrapers
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"regexp"
+	"sort"
 	"time"
 
 	"github.com/UTDNebula/api-tools/utils"
@@ -24,25 +25,147 @@ import (
 
 const CALENDAR_LINK string = "https://calendar.utdallas.edu/calendar"
 
+// Number of days to scrape when no end date is given
+const DEFAULT_EVENT_WINDOW_DAYS = 30
+
 var trailingSpaceRegex *regexp.Regexp = regexp.MustCompile(`(\s{2,}?\s{2,})|(\n)`)
 
-func ScrapeEvents(outDir string) {
+// Time location for calendar days (the calendar is in America/Chicago time)
+var calendarLocation, calendarLocationErr = time.LoadLocation("America/Chicago")
 
-	chromedpCtx, cancel := utils.InitChromeDp()
-	defer cancel()
+// Scrapes all events in the given date window.
+// Events are read from the calendar's feeds unless source is "dom"; if reading the feed fails, the rendered event pages are scraped instead.
+func ScrapeEvents(outDir string, startDate string, endDate string, source string, feedPath string, format string) {
+
+	if calendarLocationErr != nil {
+		panic(calendarLocationErr)
+	}
+
+	windowStart, windowEnd := getEventWindow(startDate, endDate)
 
 	err := os.MkdirAll(outDir, 0777)
 	if err != nil {
 		panic(err)
 	}
 
+	var events []schema.Event
+	if source != "dom" {
+		log.Printf("Reading %s event feed...", source)
+		events, err = scrapeEventFeed(source, feedPath, windowStart, windowEnd)
+		if err != nil {
+			log.Printf("WARN: Failed to read %s event feed, falling back to scraping event pages: %s", source, err)
+		} else {
+			log.Printf("Read %d events from the %s feed!", len(events), source)
+		}
+	}
+	if source == "dom" || err != nil {
+		events = scrapeEventPages(windowStart, windowEnd)
+	}
+
+	writeEvents(outDir, format, events)
+	writeEventLocations(outDir, format, events)
+}
+
+// Scrapes all events in the given date window by navigating through the rendered calendar
+func scrapeEventPages(windowStart time.Time, windowEnd time.Time) []schema.Event {
+
+	chromedpCtx, cancel := utils.InitChromeDp()
+	defer cancel()
+
 	events := []schema.Event{}
 
-	log.Printf("Scraping event page links")
-	//Grab all links to event pages
+	log.Printf("Scraping event page links from %s to %s", windowStart.Format("2006-01-02"), windowEnd.Format("2006-01-02"))
+	// Grab all links to event pages, along with every day each event occurs on
+	pageLinks, eventDays := scrapeEventDays(chromedpCtx, windowStart, windowEnd)
+	log.Printf("Scraped %d event page links!", len(pageLinks))
+
+	for _, page := range pageLinks {
+		event, ok := scrapeEventPage(chromedpCtx, page)
+		if !ok {
+			continue
+		}
+		// Recurring events show up on multiple days, so make an event for each instance
+		events = append(events, getEventInstances(event, eventDays[page])...)
+	}
+	log.Printf("Scraped %d events!", len(events))
+
+	return events
+}
+
+func writeEvents(outDir string, format string, events []schema.Event) {
+	// Write event data to output file
+	if err := utils.WriteDataFile(outDir, "events", format, events); err != nil {
+		panic(err)
+	}
+}
+
+// Parses the -start and -end dates, defaulting to a window starting today
+func getEventWindow(startDate string, endDate string) (time.Time, time.Time) {
+	var windowStart, windowEnd time.Time
+	if startDate == "" {
+		year, month, day := time.Now().In(calendarLocation).Date()
+		windowStart = time.Date(year, month, day, 0, 0, 0, 0, calendarLocation)
+	} else {
+		date, err := time.ParseInLocation("2006-01-02", startDate, calendarLocation)
+		if err != nil {
+			log.Panicf("Invalid start date '%s'! The format is YYYY-MM-DD.", startDate)
+		}
+		windowStart = date
+	}
+	if endDate == "" {
+		windowEnd = windowStart.AddDate(0, 0, DEFAULT_EVENT_WINDOW_DAYS)
+	} else {
+		date, err := time.ParseInLocation("2006-01-02", endDate, calendarLocation)
+		if err != nil {
+			log.Panicf("Invalid end date '%s'! The format is YYYY-MM-DD.", endDate)
+		}
+		windowEnd = date
+	}
+	if windowEnd.Before(windowStart) {
+		log.Panicf("End date %s is before start date %s!", windowEnd.Format("2006-01-02"), windowStart.Format("2006-01-02"))
+	}
+	return windowStart, windowEnd
+}
+
+// Pages through the calendar for each day in the window, returning the unique event links in the order they were found
+// and a mapping of each link to the days it occurs on
+func scrapeEventDays(chromedpCtx context.Context, windowStart time.Time, windowEnd time.Time) ([]string, map[string][]time.Time) {
+	pageLinks := []string{}
+	eventDays := make(map[string][]time.Time)
+
+	for day := windowStart; !day.After(windowEnd); day = day.AddDate(0, 0, 1) {
+		// Links already seen on this day, used to detect the last page
+		dayLinks := make(map[string]bool)
+		for pageNum := 1; ; pageNum++ {
+			dayURL := fmt.Sprintf("%s/day/%d/%d/%d?page=%d", CALENDAR_LINK, day.Year(), day.Month(), day.Day(), pageNum)
+			newLinks := 0
+			for _, link := range scrapeEventLinks(chromedpCtx, dayURL) {
+				if dayLinks[link] {
+					continue
+				}
+				dayLinks[link] = true
+				newLinks++
+				if _, seen := eventDays[link]; !seen {
+					pageLinks = append(pageLinks, link)
+				}
+				eventDays[link] = append(eventDays[link], day)
+			}
+			// Past the last page, the calendar either shows nothing or repeats the previous page
+			if newLinks == 0 {
+				break
+			}
+		}
+		utils.VPrintf("Found %d events on %s", len(dayLinks), day.Format("2006-01-02"))
+	}
+
+	return pageLinks, eventDays
+}
+
+// Gets the links to all event pages listed on a calendar page
+func scrapeEventLinks(chromedpCtx context.Context, calendarURL string) []string {
 	var pageLinks []string = []string{}
-	_, err = chromedp.RunResponse(chromedpCtx,
-		chromedp.Navigate(CALENDAR_LINK),
+	_, err := chromedp.RunResponse(chromedpCtx,
+		chromedp.Navigate(calendarURL),
 		chromedp.QueryAfter(".item.event_item.vevent > a",
 			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
 				for _, node := range nodes {
@@ -51,287 +174,383 @@ func ScrapeEvents(outDir string) {
 						return errors.New("event card was missing an href")
 					}
 
-					pageLinks = append(pageLinks, href)
+					pageLinks = append(pageLinks, normalizeEventLink(href))
 				}
 				return nil
-			},
+			}, chromedp.AtLeast(0),
 		),
 	)
 	if err != nil {
 		panic(err)
 	}
-	log.Printf("Scraped event page links!")
+	return pageLinks
+}
 
-	for _, page := range pageLinks {
-		//Navigate to page and get page summary
-		summary := ""
-		_, err := chromedp.RunResponse(chromedpCtx,
-			chromedp.Navigate(page),
-			chromedp.QueryAfter(".summary",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						summary = trailingSpaceRegex.ReplaceAllString(getNodeText(nodes[0]), "")
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
+// Strips the query and fragment from an event link so the same event is always keyed the same way
+func normalizeEventLink(link string) string {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	linkURL.RawQuery = ""
+	linkURL.Fragment = ""
+	return linkURL.String()
+}
 
-		if err != nil {
-			panic(err)
-		}
-		utils.VPrintf("Navigated to page %s", summary)
-
-		// Grab date/time of the event
-		var dateTimeStart time.Time
-		var dateTimeEnd time.Time
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".dtstart",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						timeStamp, hasTime := nodes[0].Attribute("title")
-						if !hasTime {
-							return errors.New("event does not have a start time")
-						}
-						formattedTime, err := time.Parse(time.RFC3339, timeStamp)
-						if err != nil {
-							return err
-						}
-
-						dateTimeStart = formattedTime
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-			chromedp.QueryAfter(".dtend",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						timeStamp, hasTime := nodes[0].Attribute("title")
-						if !hasTime {
-							return errors.New("event does not have an end time")
-						}
-						formattedTime, err := time.Parse(time.RFC3339, timeStamp)
-						if err != nil {
-							return err
-						}
-
-						dateTimeEnd = formattedTime
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
+// Makes one event per occurrence of an event, given the days it's listed on. Events that last several days are listed on each of them,
+// so days already covered by the previous occurrence don't start a new one.
+func getEventInstances(event schema.Event, days []time.Time) []schema.Event {
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	instances := make([]schema.Event, 0, len(days))
+	var coveredUntil time.Time
+	for _, day := range days {
+		if day.Before(coveredUntil) {
 			continue
 		}
-		utils.VPrintf("Scraped time: %s to %s ", dateTimeStart, dateTimeEnd)
-
-		//Grab Location of Event
-		var location string = ""
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter("p.location > span",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						location = getNodeText(nodes[0])
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			continue
+		instance := event
+		instance.Id = primitive.NewObjectID()
+		switch {
+		case event.StartTime.IsZero():
+			instance.StartTime = day
+		case !event.EndTime.IsZero() && event.StartTime.Before(day.AddDate(0, 0, 1)) && event.EndTime.After(day):
+			// The scraped times are for the occurrence on this day, which may have started on an earlier one
+		default:
+			instance.StartTime = moveToDay(event.StartTime, day)
+			if !event.EndTime.IsZero() {
+				// Keep the event's duration, since it may end on a later day than it starts
+				instance.EndTime = instance.StartTime.Add(event.EndTime.Sub(event.StartTime))
+			}
 		}
-		utils.VPrintf("Scraped location: %s, ", location)
-
-		//Get description of event
-		var description string = ""
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".description > p",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						description = getNodeText(nodes[0])
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			continue
+		// The occurrence covers every day up to the one it ends on
+		coveredUntil = day.AddDate(0, 0, 1)
+		if instance.EndTime.After(coveredUntil) {
+			coveredUntil = instance.EndTime
 		}
-		utils.VPrintf("Scraped description: %s, ", description)
-
-		//Grab Event Type
-		var eventType []string = []string{}
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".filter-event_types > p > a",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					for _, node := range nodes {
-						eventType = append(eventType, getNodeText(node))
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+// Keeps the clock time of t, but moves it onto the given calendar day
+func moveToDay(t time.Time, day time.Time) time.Time {
+	local := t.In(calendarLocation)
+	return time.Date(day.Year(), day.Month(), day.Day(), local.Hour(), local.Minute(), local.Second(), 0, calendarLocation)
+}
+
+// Scrapes the event page at the given link, reporting false if the page couldn't be scraped
+func scrapeEventPage(chromedpCtx context.Context, page string) (schema.Event, bool) {
+	//Navigate to page and get page summary
+	summary := ""
+	_, err := chromedp.RunResponse(chromedpCtx,
+		chromedp.Navigate(page),
+		chromedp.QueryAfter(".summary",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					summary = trailingSpaceRegex.ReplaceAllString(getNodeText(nodes[0]), "")
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Navigated to page %s", summary)
+
+	// Grab date/time of the event
+	var dateTimeStart time.Time
+	var dateTimeEnd time.Time
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".dtstart",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					timeStamp, hasTime := nodes[0].Attribute("title")
+					if !hasTime {
+						return errors.New("event does not have a start time")
 					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			panic(err)
-		}
-		utils.VPrintf("Scraped event type: %s", eventType)
-
-		//Grab Target Audience
-		targetAudience := []string{}
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".filter-event_target_audience > p > a",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					for _, node := range nodes {
-						targetAudience = append(targetAudience, getNodeText(node))
+					formattedTime, err := time.Parse(time.RFC3339, timeStamp)
+					if err != nil {
+						return err
 					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			panic(err)
-		}
-		utils.VPrintf("Scraped target audience: %s, ", targetAudience)
-
-		//Grab Topic
-		topic := []string{}
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".filter-event_topic > p > a",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					for _, node := range nodes {
-						topic = append(topic, getNodeText(node))
+
+					dateTimeStart = formattedTime
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+		chromedp.QueryAfter(".dtend",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					timeStamp, hasTime := nodes[0].Attribute("title")
+					if !hasTime {
+						return errors.New("event does not have an end time")
 					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			panic(err)
-		}
-		utils.VPrintf("Scraped topic: %s, ", topic)
-
-		//Grab Event Tags
-		tags := []string{}
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".event-tags > p > a",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					for _, node := range nodes {
-						tags = append(tags, getNodeText(node))
+					formattedTime, err := time.Parse(time.RFC3339, timeStamp)
+					if err != nil {
+						return err
 					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			panic(err)
-		}
-		utils.VPrintf("Scraped tags: %s, ", tags)
-
-		//Grab Website
-		var eventWebsite string = ""
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".event-website > p > a",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						href, hasHref := nodes[0].Attribute("href")
-						if !hasHref {
-							return errors.New("event does not have website")
-						}
-						eventWebsite = href
+
+					dateTimeEnd = formattedTime
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped time: %s to %s ", dateTimeStart, dateTimeEnd)
+
+	//Grab Location of Event
+	var location string = ""
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter("p.location > span",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					location = getNodeText(nodes[0])
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped location: %s, ", location)
+
+	//Get description of event
+	var description string = ""
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".description > p",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					description = getNodeText(nodes[0])
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped description: %s, ", description)
+
+	//Grab Event Type
+	var eventType []string = []string{}
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".filter-event_types > p > a",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				for _, node := range nodes {
+					eventType = append(eventType, getNodeText(node))
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped event type: %s", eventType)
+
+	//Grab Target Audience
+	targetAudience := []string{}
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".filter-event_target_audience > p > a",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				for _, node := range nodes {
+					targetAudience = append(targetAudience, getNodeText(node))
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped target audience: %s, ", targetAudience)
+
+	//Grab Topic
+	topic := []string{}
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".filter-event_topic > p > a",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				for _, node := range nodes {
+					topic = append(topic, getNodeText(node))
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped topic: %s, ", topic)
+
+	//Grab Event Tags
+	tags := []string{}
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".event-tags > p > a",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				for _, node := range nodes {
+					tags = append(tags, getNodeText(node))
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped tags: %s, ", tags)
+
+	//Grab Website
+	var eventWebsite string = ""
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".event-website > p > a",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					href, hasHref := nodes[0].Attribute("href")
+					if !hasHref {
+						return errors.New("event does not have website")
 					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			continue
-		}
-		utils.VPrintf("Scraped website: %s, ", eventWebsite)
-
-		//Grab Department
-		var eventDepartment []string = []string{}
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".event-group > a",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					for _, node := range nodes {
-						eventDepartment = append(eventDepartment, getNodeText(node))
+					eventWebsite = href
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped website: %s, ", eventWebsite)
+
+	//Grab Department
+	var eventDepartment []string = []string{}
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".event-group > a",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				for _, node := range nodes {
+					eventDepartment = append(eventDepartment, getNodeText(node))
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped department: %s, ", eventDepartment)
+
+	//Grab Contact information
+	var contactInformationName string = ""
+	var contactInformationEmail string = ""
+	var contactInformationPhone string = ""
+	err = chromedp.Run(chromedpCtx,
+		chromedp.QueryAfter(".custom-field-contact_information_name",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					contactInformationName = getNodeText(nodes[0])
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+		chromedp.QueryAfter(".custom-field-contact_information_email",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					contactInformationEmail = getNodeText(nodes[0])
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+		chromedp.QueryAfter(".custom-field-contact_information_phone",
+			func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if len(nodes) != 0 {
+					contactInformationPhone = getNodeText(nodes[0])
+					if err != nil {
+						return err
 					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			panic(err)
+				}
+				return nil
+			}, chromedp.AtLeast(0),
+		),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to scrape event page %s: %s", page, err)
+		return schema.Event{}, false
+	}
+	utils.VPrintf("Scraped contact name info: %s", contactInformationName)
+	utils.VPrintf("Scraped contact email info: %s", contactInformationEmail)
+	utils.VPrintf("Scraped contact phone info: %s", contactInformationPhone)
+
+	return schema.Event{
+		Id:                 primitive.NewObjectID(),
+		Summary:            summary,
+		Location:           location,
+		StartTime:          dateTimeStart,
+		EndTime:            dateTimeEnd,
+		Description:        description,
+		EventType:          eventType,
+		TargetAudience:     targetAudience,
+		Topic:              topic,
+		EventTags:          tags,
+		EventWebsite:       eventWebsite,
+		Department:         eventDepartment,
+		ContactName:        contactInformationName,
+		ContactEmail:       contactInformationEmail,
+		ContactPhoneNumber: contactInformationPhone,
+	}, true
+}
+
+// Structured location resolved from an event's free-text location
+type EventLocation struct {
+	Event    primitive.ObjectID `bson:"event" json:"event"`
+	Text     string             `bson:"text" json:"text"`
+	Resolved bool               `bson:"resolved" json:"resolved"`
+	Location schema.Location    `bson:"location" json:"location"`
+}
+
+// Resolves each event's location to a campus building and room, writing the results alongside the events
+// and reporting any location strings that couldn't be resolved
+func writeEventLocations(outDir string, format string, events []schema.Event) {
+	eventLocations := make([]EventLocation, 0, len(events))
+	unresolvedCounts := make(map[string]int)
+	for _, event := range events {
+		if event.Location == "" {
+			continue
 		}
-		utils.VPrintf("Scraped department: %s, ", eventDepartment)
-
-		//Grab Contact information
-		var contactInformationName string = ""
-		var contactInformationEmail string = ""
-		var contactInformationPhone string = ""
-		err = chromedp.Run(chromedpCtx,
-			chromedp.QueryAfter(".custom-field-contact_information_name",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						contactInformationName = getNodeText(nodes[0])
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-			chromedp.QueryAfter(".custom-field-contact_information_email",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						contactInformationEmail = getNodeText(nodes[0])
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-			chromedp.QueryAfter(".custom-field-contact_information_phone",
-				func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					if len(nodes) != 0 {
-						contactInformationPhone = getNodeText(nodes[0])
-						if err != nil {
-							return err
-						}
-					}
-					return nil
-				}, chromedp.AtLeast(0),
-			),
-		)
-		if err != nil {
-			panic(err)
+		location, resolved := utils.ParseLocation(event.Location)
+		if !resolved {
+			unresolvedCounts[event.Location]++
 		}
-		utils.VPrintf("Scraped contact name info: %s", contactInformationName)
-		utils.VPrintf("Scraped contact email info: %s", contactInformationEmail)
-		utils.VPrintf("Scraped contact phone info: %s", contactInformationPhone)
-
-		events = append(events, schema.Event{
-			Id:                 primitive.NewObjectID(),
-			Summary:            summary,
-			Location:           location,
-			StartTime:          dateTimeStart,
-			EndTime:            dateTimeEnd,
-			Description:        description,
-			EventType:          eventType,
-			TargetAudience:     targetAudience,
-			Topic:              topic,
-			EventTags:          tags,
-			EventWebsite:       eventWebsite,
-			Department:         eventDepartment,
-			ContactName:        contactInformationName,
-			ContactEmail:       contactInformationEmail,
-			ContactPhoneNumber: contactInformationPhone,
+		eventLocations = append(eventLocations, EventLocation{
+			Event:    event.Id,
+			Text:     event.Location,
+			Resolved: resolved,
+			Location: location,
 		})
 	}
 
-	// Write event data to output file
-	fptr, err := os.Create(fmt.Sprintf("%s/events.json", outDir))
-	if err != nil {
+	if len(unresolvedCounts) > 0 {
+		unresolved := utils.GetMapKeys(unresolvedCounts)
+		sort.Strings(unresolved)
+		log.Printf("Couldn't resolve %d event locations to a campus building:", len(unresolved))
+		for _, text := range unresolved {
+			log.Printf("\t'%s' (%d events)", text, unresolvedCounts[text])
+		}
+	}
+
+	if err := utils.WriteDataFile(outDir, "event_locations", format, eventLocations); err != nil {
 		panic(err)
 	}
-	encoder := json.NewEncoder(fptr)
-	encoder.SetIndent("", "\t")
-	encoder.Encode(events)
-	fptr.Close()
 }
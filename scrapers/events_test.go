@@ -0,0 +1,34 @@
+package scrapers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+func TestGetEventInstances(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 0, 0, 0, 0, calendarLocation) }
+	at := func(d int, hour int) time.Time {
+		return time.Date(2024, time.March, d, hour, 0, 0, 0, calendarLocation)
+	}
+
+	// A 3 day conference is listed on each of its days, but only happens once
+	conference := schema.Event{StartTime: at(4, 9), EndTime: at(6, 17)}
+	instances := getEventInstances(conference, []time.Time{day(6), day(4), day(5), day(5)})
+	if len(instances) != 1 || !instances[0].StartTime.Equal(at(4, 9)) || !instances[0].EndTime.Equal(at(6, 17)) {
+		t.Fatalf("got %+v, want a single instance from the 4th to the 6th", instances)
+	}
+
+	// An evening meeting that repeats daily happens on each day it's listed on
+	meeting := schema.Event{StartTime: at(4, 18), EndTime: at(4, 19)}
+	instances = getEventInstances(meeting, []time.Time{day(4), day(5), day(7)})
+	if len(instances) != 3 {
+		t.Fatalf("got %d instances, want 3", len(instances))
+	}
+	for i, d := range []int{4, 5, 7} {
+		if !instances[i].StartTime.Equal(at(d, 18)) || !instances[i].EndTime.Equal(at(d, 19)) {
+			t.Errorf("instance %d runs from %v to %v, want 6pm to 7pm on the %dth", i, instances[i].StartTime, instances[i].EndTime, d)
+		}
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/csv"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -44,7 +43,7 @@ var (
 	emailRegex       = regexp.MustCompile(fmt.Sprintf(`%s@%s%s`, localPartPattern, subdomainPattern, topdomainPattern))
 )
 
-func ScrapeOrganizations(outdir string) {
+func ScrapeOrganizations(outdir string, format string) {
 	log.Println("Scraping SOC ...")
 	if err := godotenv.Load(); err != nil {
 		log.Panic("error loading .env file")
@@ -61,7 +60,7 @@ func ScrapeOrganizations(outdir string) {
 	if err := loginToSoc(ctx); err != nil {
 		panic(err)
 	}
-	if err := scrapeData(ctx, outdir); err != nil {
+	if err := scrapeData(ctx, outdir, format); err != nil {
 		panic(err)
 	}
 }
@@ -102,7 +101,7 @@ func loginToSoc(ctx context.Context) error {
 	return err
 }
 
-func scrapeData(ctx context.Context, outdir string) error {
+func scrapeData(ctx context.Context, outdir string, format string) error {
 	log.Println("Scraping data ...")
 	// download file method adapted from https://github.com/chromedp/examples/blob/master/download_file/main.go
 	timedCtx, cancel := context.WithTimeout(ctx, time.Minute)
@@ -146,38 +145,30 @@ func scrapeData(ctx context.Context, outdir string) error {
 		os.Remove(guidPath)
 	}()
 
-	outPath := filepath.Join(outdir, "organizations.json")
-
-	if err := processCsv(ctx, guidPath, outPath); err != nil {
+	orgs, err := processCsv(ctx, guidPath)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return utils.WriteDataFile(outdir, "organizations", format, orgs)
 }
 
-func processCsv(ctx context.Context, inputPath string, storageFilePath string) error {
+func processCsv(ctx context.Context, inputPath string) ([]*schema.Organization, error) {
 	// open csv for reading
 	csvFile, err := os.Open(inputPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer csvFile.Close()
 
 	// init csv reader
 	bufReader := bufio.NewReader(csvFile)
 	// discard headers
 	if _, _, err := bufReader.ReadLine(); err != nil {
-		return err
+		return nil, err
 	}
 	csvReader := csv.NewReader(bufReader)
 
-	// write to json
-	storageFile, err := os.Create(storageFilePath)
-	if err != nil {
-		return err
-	}
-	encoder := json.NewEncoder(bufio.NewWriter(storageFile))
-	encoder.SetIndent("", "\t")
-
 	var orgs []*schema.Organization
 	// process each row of csv
 	for i := 1; true; i++ {
@@ -186,32 +177,19 @@ func processCsv(ctx context.Context, inputPath string, storageFilePath string) e
 			if err == io.EOF {
 				break
 			}
-			return err
+			return nil, err
 		}
 
 		utils.VPrintf("Processing row %d", i)
 		org, err := parseCsvRecord(ctx, entry)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		orgs = append(orgs, org)
 	}
 
-	// Write JSON to file
-	if err = encoder.Encode(orgs); err != nil {
-		return err
-	}
-
-	if err := csvFile.Close(); err != nil {
-		return err
-	}
-
-	if err := storageFile.Close(); err != nil {
-		return err
-	}
-
-	return nil
+	return orgs, nil
 }
 
 func parseCsvRecord(ctx context.Context, entry []string) (*schema.Organization, error) {
@@ -6,12 +6,9 @@ package scrapers
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -25,32 +22,9 @@ import (
 
 const BASE_URL string = "https://profiles.utdallas.edu/browse?page="
 
-var primaryLocationRegex *regexp.Regexp = regexp.MustCompile(`^(\w+)\s+(\d+\.\d{3}[A-z]?)$`)
-var fallbackLocationRegex *regexp.Regexp = regexp.MustCompile(`^([A-z]+)(\d+)\.?(\d{3}[A-z]?)$`)
-
 func parseLocation(text string) schema.Location {
-	var building string
-	var room string
-
-	submatches := primaryLocationRegex.FindStringSubmatch(text)
-	if submatches == nil {
-		submatches = fallbackLocationRegex.FindStringSubmatch(text)
-		if submatches == nil {
-			return schema.Location{}
-		} else {
-			building = submatches[1]
-			room = fmt.Sprintf("%s.%s", submatches[2], submatches[3])
-		}
-	} else {
-		building = submatches[1]
-		room = submatches[2]
-	}
-
-	return schema.Location{
-		Building: building,
-		Room:     room,
-		Map_uri:  fmt.Sprintf("https://locator.utdallas.edu/%s_%s", building, room),
-	}
+	location, _ := utils.ParseLocation(text)
+	return location
 }
 
 func parseList(list []string) (string, schema.Location) {
@@ -62,7 +36,7 @@ func parseList(list []string) (string, schema.Location) {
 		utils.VPrintf("Element is: %s", element)
 		if strings.Contains(element, "-") {
 			phoneNumber = element
-		} else if primaryLocationRegex.MatchString(element) || fallbackLocationRegex.MatchString(element) {
+		} else if utils.IsRoomLocation(element) {
 			utils.VPrintf("Element match is: %s", element)
 			office = parseLocation(element)
 			break
@@ -141,7 +115,7 @@ func scrapeProfessorLinks(chromedpCtx context.Context) []string {
 	return professorLinks
 }
 
-func ScrapeProfiles(outDir string) {
+func ScrapeProfiles(outDir string, format string) {
 
 	chromedpCtx, cancel := utils.InitChromeDp()
 	defer cancel()
@@ -275,7 +249,7 @@ func ScrapeProfiles(outDir string) {
 		utils.VPrintf("Parsed list! #: %s, Office: %v", phoneNumber, office)
 
 		professors = append(professors, schema.Professor{
-			Id:           primitive.NewObjectID(),
+			Id:           utils.DeterministicID("professor", firstName, lastName),
 			First_name:   firstName,
 			Last_name:    lastName,
 			Titles:       titles,
@@ -292,12 +266,7 @@ func ScrapeProfiles(outDir string) {
 	}
 
 	// Write professor data to output file
-	fptr, err := os.Create(fmt.Sprintf("%s/profiles.json", outDir))
-	if err != nil {
+	if err := utils.WriteDataFile(outDir, "profiles", format, professors); err != nil {
 		panic(err)
 	}
-	encoder := json.NewEncoder(fptr)
-	encoder.SetIndent("", "\t")
-	encoder.Encode(professors)
-	fptr.Close()
 }
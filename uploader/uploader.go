@@ -6,10 +6,9 @@ package uploader
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"time"
@@ -19,20 +18,34 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/UTDNebula/api-tools/parser"
+	"github.com/UTDNebula/api-tools/utils"
 	"github.com/UTDNebula/nebula-api/api/schema"
 	"github.com/joho/godotenv"
 )
 
 //  It's important to note that all of the files must be updated/uploaded TOGETHER!
-//  This is because the parser links all of the data together with ObjectID references, and
-//  these references will change and cause things to break if files are updated/uploaded individually!
+//  This is because the parser links all of the data together with ObjectID references.
+//  The parser derives these ObjectIDs from each document's natural key, so re-parsing the same data produces the same IDs:
+//  courses from their internal course number and catalog year, professors from their name, and sections from their number, course, and term name.
+//  These aren't exactly the merge filters below (i.e. courses are merged on their subject, number, and catalog year),
+//  and documents that are new or removed still need their references updated together!
+//  Data uploaded before IDs were derived this way has random IDs, so the first upload of derived IDs over it must use -replace:
+//  sections would be inserted again next to their old copies, and courses and professors can't have their IDs changed by a merge.
 
 //  Also note that this uploader assumes that the collection names match the names of these files, which they should.
 //  If the names of these collections ever change, the file names should be updated accordingly.
 
-var filesToUpload [3]string = [3]string{"courses.json", "professors.json", "sections.json"}
+var filesToUpload [3]string = [3]string{"courses", "professors", "sections"}
 
-func Upload(inDir string, replace bool) {
+// Files that are only uploaded if they were produced, since they depend on optional inputs (grade statistics need grade data)
+var optionalFilesToUpload [1]string = [1]string{"grade_stats"}
+
+// How many documents are inserted at a time, so files are streamed instead of read into memory all at once
+const UPLOAD_BATCH_SIZE = 1000
+
+// Uploads the parsed data in inDir. If termSubset is set, the data only covers some terms, so the sections of uploaded courses and professors are added to their existing ones.
+func Upload(inDir string, replace bool, termSubset bool, format string) {
 
 	//Load env vars
 	if err := godotenv.Load(); err != nil {
@@ -46,98 +59,93 @@ func Upload(inDir string, replace bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	for _, path := range filesToUpload {
+	for _, name := range filesToUpload {
 
-		// Open data file for reading
-		fptr, err := os.Open(fmt.Sprintf("%s/"+path, inDir))
-		if err != nil {
-			log.Panic(err)
+		path := utils.DataFilePath(inDir, name, format)
+
+		switch name {
+		case "courses":
+			UploadData[schema.Course](client, ctx, path, replace, termSubset)
+		case "professors":
+			UploadData[schema.Professor](client, ctx, path, replace, termSubset)
+		case "sections":
+			UploadData[schema.Section](client, ctx, path, replace, termSubset)
 		}
+	}
+
+	for _, name := range optionalFilesToUpload {
 
-		defer fptr.Close()
+		path := utils.DataFilePath(inDir, name, format)
+		if _, err := os.Stat(path); err != nil {
+			log.Printf("Couldn't find/open %s. Skipping it.", path)
+			continue
+		}
 
-		switch path {
-		case "courses.json":
-			UploadData[schema.Course](client, ctx, fptr, replace)
-		case "professors.json":
-			UploadData[schema.Professor](client, ctx, fptr, replace)
-		case "sections.json":
-			UploadData[schema.Section](client, ctx, fptr, replace)
+		switch name {
+		case "grade_stats":
+			UploadData[parser.GradeStats](client, ctx, path, replace, termSubset)
 		}
 	}
 
 }
 
-// Generic upload function to upload parsed JSON data to the Mongo database
+// Generic upload function to upload parsed JSON or NDJSON data to the Mongo database
 // Make sure that the name of the file being parsed matches with the name of the collection you are uploading to!
-// For example, your file should be named courses.json if you want to upload courses
-// As of right now, courses, professors, and sections are available to upload.
-func UploadData[T any](client *mongo.Client, ctx context.Context, fptr *os.File, replace bool) {
-	fileName := fptr.Name()[strings.LastIndex(fptr.Name(), "/")+1 : len(fptr.Name())-5]
-	log.Println("Uploading " + fileName + ".json ...")
-
-	// Decode documents from file
-	var docs []T
-	decoder := json.NewDecoder(fptr)
-	err := decoder.Decode(&docs)
+// For example, your file should be named courses.json (or courses.ndjson) if you want to upload courses
+// As of right now, courses, professors, sections, and grade_stats are available to upload.
+// If termSubset is set, matched courses and professors keep their existing sections, with any uploaded sections added to them.
+func UploadData[T any](client *mongo.Client, ctx context.Context, path string, replace bool, termSubset bool) {
+	fileName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	log.Println("Uploading " + filepath.Base(path) + " ...")
+
+	// Documents are streamed into a temporary collection first, so the real collection is only touched once the whole file has been read
+	collection := getCollection(client, "temp")
+
+	// If a temp collection already exists, drop it
+	err := collection.Drop(ctx)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	if replace {
-
-		// Get collection
-		collection := getCollection(client, fileName)
+	// Create a temporary collection
+	err = client.Database("combinedDB").CreateCollection(ctx, "temp")
+	if err != nil {
+		log.Panic(err)
+	}
 
-		// Delete all documents from collection
-		_, err := collection.DeleteMany(ctx, bson.D{})
-		if err != nil {
-			log.Panic(err)
+	// Stream documents from the file into the collection in batches
+	opts := options.InsertMany().SetOrdered(false)
+	batch := make([]interface{}, 0, UPLOAD_BATCH_SIZE)
+	insertBatch := func() error {
+		if len(batch) == 0 {
+			return nil
 		}
-
-		// Convert your documents to []interface{}
-		docsInterface := make([]interface{}, len(docs))
-		for i := range docs {
-			docsInterface[i] = docs[i]
+		_, err := collection.InsertMany(ctx, batch, opts)
+		batch = batch[:0]
+		return err
+	}
+	err = utils.ReadDataFile(path, func(doc T) error {
+		batch = append(batch, doc)
+		if len(batch) == UPLOAD_BATCH_SIZE {
+			return insertBatch()
 		}
+		return nil
+	})
+	if err == nil {
+		err = insertBatch()
+	}
+	if err != nil {
+		log.Panic(err)
+	}
 
-		// Add all documents decoded from the file into the collection
-		opts := options.InsertMany().SetOrdered(false)
-		_, err = collection.InsertMany(ctx, docsInterface, opts)
+	if replace {
+		// Replace the collection's documents with the temporary collection's all at once, keeping the collection's indexes
+		outStage := bson.D{primitive.E{Key: "$out", Value: fileName}}
+		_, err = collection.Aggregate(ctx, mongo.Pipeline{outStage})
 		if err != nil {
 			log.Panic(err)
 		}
-
 	} else {
-		// If a temp collection already exists, drop it
-		tempCollection := getCollection(client, "temp")
-		err = tempCollection.Drop(ctx)
-		if err != nil {
-			log.Panic(err)
-		}
-
-		// Create a temporary collection
-		err := client.Database("combinedDB").CreateCollection(ctx, "temp")
-		if err != nil {
-			log.Panic(err)
-		}
-
-		// Get the temporary collection
-		tempCollection = getCollection(client, "temp")
-
-		// Convert your documents to []interface{}
-		docsInterface := make([]interface{}, len(docs))
-		for i := range docs {
-			docsInterface[i] = docs[i]
-		}
-
-		// Add all documents decoded from the file into the temporary collection
-		opts := options.InsertMany().SetOrdered(false)
-		_, err = tempCollection.InsertMany(ctx, docsInterface, opts)
-		if err != nil {
-			log.Panic(err)
-		}
-
 		// Create a merge aggregate pipeline
 		// Matched documents from the temporary collection will replace matched documents from the Mongo collection
 		// Unmatched documents from the temporary collection will be inserted into the Mongo collection
@@ -148,29 +156,44 @@ func UploadData[T any](client *mongo.Client, ctx context.Context, fptr *os.File,
 		case "professors":
 			matchFilters = []string{"first_name", "last_name"}
 		case "sections":
-			matchFilters = []string{"section_number", "course_reference", "academic_session"}
+			// Section IDs are derived from their number, course, and term, so they're matched on directly; matching on the whole academic
+			// session would miss sections made from grade data alone, which have no session dates, once their term is scraped
+			matchFilters = []string{"_id"}
+		case "grade_stats":
+			// Statistics IDs are derived from what they're for, so they can be matched on directly
+			matchFilters = []string{"_id"}
 		default:
 			log.Panic("Unrecognizable filename: " + fileName)
 		}
 
+		// Data for only some terms only lists the sections in those terms, so add them to the sections matched documents already list
+		var whenMatched interface{} = "replace"
+		if termSubset && (fileName == "courses" || fileName == "professors") {
+			whenMatched = mongo.Pipeline{bson.D{primitive.E{Key: "$replaceWith", Value: bson.D{primitive.E{Key: "$mergeObjects", Value: bson.A{
+				"$$new",
+				bson.D{primitive.E{Key: "sections", Value: bson.D{primitive.E{Key: "$setUnion", Value: bson.A{
+					bson.D{primitive.E{Key: "$ifNull", Value: bson.A{"$sections", bson.A{}}}},
+					bson.D{primitive.E{Key: "$ifNull", Value: bson.A{"$$new.sections", bson.A{}}}},
+				}}}}},
+			}}}}}}
+		}
+
 		// The documents will be added/merged into the collection with the same name as the file
 		// The filters for the merge aggregate pipeline are based on the file name
-		mergeStage := bson.D{primitive.E{Key: "$merge", Value: bson.D{primitive.E{Key: "into", Value: fileName}, primitive.E{Key: "on", Value: matchFilters}, primitive.E{Key: "whenMatched", Value: "replace"}, primitive.E{Key: "whenNotMatched", Value: "insert"}}}}
+		mergeStage := bson.D{primitive.E{Key: "$merge", Value: bson.D{primitive.E{Key: "into", Value: fileName}, primitive.E{Key: "on", Value: matchFilters}, primitive.E{Key: "whenMatched", Value: whenMatched}, primitive.E{Key: "whenNotMatched", Value: "insert"}}}}
 
 		// Execute aggregate pipeline
-		_, err = tempCollection.Aggregate(ctx, mongo.Pipeline{mergeStage})
-		if err != nil {
-			log.Panic(err)
-		}
-
-		// Drop the temporary collection
-		err = tempCollection.Drop(ctx)
+		_, err = collection.Aggregate(ctx, mongo.Pipeline{mergeStage})
 		if err != nil {
 			log.Panic(err)
 		}
 	}
 
-	log.Println("Done uploading " + fileName + ".json!")
+	// Drop the temporary collection
+	err = collection.Drop(ctx)
+	if err != nil {
+		log.Panic(err)
+	}
 
-	defer fptr.Close()
+	log.Println("Done uploading " + filepath.Base(path) + "!")
 }
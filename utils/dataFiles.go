@@ -0,0 +1,119 @@
+/*
+	This file contains the readers and writers for data files, which hold arrays of documents either as
+	one indented JSON array or as newline-delimited JSON (one document per line).
+*/
+
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Data file formats
+const (
+	FORMAT_JSON   = "json"
+	FORMAT_NDJSON = "ndjson"
+)
+
+// Checks that the format is one of the data file formats
+func ValidateFormat(format string) error {
+	if format != FORMAT_JSON && format != FORMAT_NDJSON {
+		return fmt.Errorf("unknown data file format '%s', expected '%s' or '%s'", format, FORMAT_JSON, FORMAT_NDJSON)
+	}
+	return nil
+}
+
+// Gets the path of the data file with the given name (i.e. "courses") in dir, using the format's file extension
+func DataFilePath(dir string, name string, format string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.%s", name, format))
+}
+
+// Writes data, which must be a slice, to the data file with the given name in dir
+func WriteDataFile(dir string, name string, format string, data interface{}) error {
+	path := DataFilePath(dir, name, format)
+	switch format {
+	case FORMAT_JSON:
+		return WriteJSON(path, data)
+	case FORMAT_NDJSON:
+		return WriteNDJSON(path, data)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// Writes each element of data, which must be a slice, to filepath as its own line of JSON
+func WriteNDJSON(filepath string, data interface{}) error {
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return fmt.Errorf("can't write %T as NDJSON, expected a slice", data)
+	}
+	fptr, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	defer fptr.Close()
+	writer := bufio.NewWriter(fptr)
+	// Encode adds the newline after each document
+	encoder := json.NewEncoder(writer)
+	for i := 0; i < value.Len(); i++ {
+		if err := encoder.Encode(value.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return fptr.Close()
+}
+
+// Streams each document in the data file at path to handle, one at a time.
+// Files ending in .ndjson are read as newline-delimited JSON, and anything else as a JSON array.
+func ReadDataFile[T any](path string, handle func(T) error) error {
+	fptr, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fptr.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(fptr))
+	isArray := !strings.HasSuffix(path, "."+FORMAT_NDJSON)
+	if isArray {
+		// Read open bracket
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for decoder.More() {
+		var doc T
+		if err := decoder.Decode(&doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := handle(doc); err != nil {
+			return err
+		}
+	}
+	if isArray {
+		// Read closing bracket
+		if _, err := decoder.Token(); err != nil && err != io.EOF {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Reads every document in the data file at path
+func ReadDataFileAll[T any](path string) ([]T, error) {
+	docs := []T{}
+	err := ReadDataFile(path, func(doc T) error {
+		docs = append(docs, doc)
+		return nil
+	})
+	return docs, err
+}
@@ -0,0 +1,174 @@
+/*
+	This file contains the campus location resolver, which turns free-text locations like "ECSS 2.415" or
+	"Student Union Galaxy Rooms" into structured locations using a curated table of building aliases.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// Building aliases, mapping lowercased building names to building abbreviations
+var buildingAliases = map[string]string{
+	"administration building":                      "AD",
+	"arts and humanities building":                 "AH1",
+	"arts and humanities 2":                        "AH2",
+	"edith o'donnell arts and technology building": "ATC",
+	"arts and technology building":                 "ATC",
+	"bioengineering and sciences building":         "BSB",
+	"callier center richardson":                    "CR",
+	"classroom building":                           "CB",
+	"davidson-gundy alumni center":                 "DGAC",
+	"alumni center":                                "DGAC",
+	"engineering and computer science north":       "ECSN",
+	"engineering and computer science south":       "ECSS",
+	"engineering and computer science west":        "ECSW",
+	"founders building":                            "FO",
+	"founders north":                               "FN",
+	"green hall":                                   "GR",
+	"cecil h. green hall":                          "GR",
+	"hoblitzelle hall":                             "HH",
+	"jonsson building":                             "JO",
+	"naveen jindal school of management":           "JSOM",
+	"jindal school of management":                  "JSOM",
+	"eugene mcdermott library":                     "MC",
+	"mcdermott library":                            "MC",
+	"sciences building":                            "SCI",
+	"student learning center":                      "SLC",
+	"student services building":                    "SSB",
+	"student services building addition":           "SSA",
+	"student union":                                "SU",
+	"visitor center and university bookstore":      "VCB",
+}
+
+// Venue aliases, mapping lowercased names of well-known rooms to the building they're in
+var venueAliases = map[string]string{
+	"galaxy rooms":             "SU",
+	"galaxy room":              "SU",
+	"jonsson performance hall": "JO",
+	"visitor center":           "VCB",
+}
+
+// Set of known building abbreviations, derived from the alias table
+var buildingCodes = func() map[string]bool {
+	codes := make(map[string]bool, len(buildingAliases))
+	for _, code := range buildingAliases {
+		codes[code] = true
+	}
+	return codes
+}()
+
+// Aliases sorted longest first, so that e.g. "student services building addition" wins over "student services building"
+var sortedAliases = func() []string {
+	aliases := append(GetMapKeys(buildingAliases), GetMapKeys(venueAliases)...)
+	sort.Slice(aliases, func(i, j int) bool {
+		if len(aliases[i]) != len(aliases[j]) {
+			return len(aliases[i]) > len(aliases[j])
+		}
+		return aliases[i] < aliases[j]
+	})
+	return aliases
+}()
+
+// Case-insensitive matchers for each alias as whole words, in the same order as sortedAliases
+var aliasRegexps = func() []*regexp.Regexp {
+	regexps := make([]*regexp.Regexp, len(sortedAliases))
+	for i, alias := range sortedAliases {
+		regexps[i] = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(alias) + `\b`)
+	}
+	return regexps
+}()
+
+// Building + room, i.e. ECSS 2.415
+var primaryLocationRegexp *regexp.Regexp = regexp.MustCompile(`^(\w+)\s+(\d+\.\d{3}[A-Za-z]?)$`)
+
+// Building + room without a space or floor dot, i.e. ECSS2415
+var fallbackLocationRegexp *regexp.Regexp = regexp.MustCompile(`^([A-Za-z]+)(\d+)\.?(\d{3}[A-Za-z]?)$`)
+
+// Room number anywhere in a string, i.e. 2.415
+var roomNumberRegexp *regexp.Regexp = regexp.MustCompile(`\b(\d+\.\d{3}[A-Za-z]?)\b`)
+
+// Room number at the start of a string, i.e. the rest of "SU 2.602" after the building abbreviation
+var leadingRoomNumberRegexp *regexp.Regexp = regexp.MustCompile(`^[\s,\-]*\d+\.\d{3}[A-Za-z]?\b`)
+
+// Words that could be building abbreviations, split on spaces, commas, and hyphens like the rest of a location
+var abbreviationRegexp *regexp.Regexp = regexp.MustCompile(`[^\s,\-]+`)
+
+// Characters left over between a building name and a named room, i.e. the comma in "Student Union, Galaxy Rooms"
+var locationSeparatorRegexp *regexp.Regexp = regexp.MustCompile(`^[\s,\-–:]+|[\s,\-–:]+$`)
+
+// Reports whether the text is strictly a building + room, i.e. ECSS 2.415 or ECSS2.415
+func IsRoomLocation(text string) bool {
+	return primaryLocationRegexp.MatchString(text) || fallbackLocationRegexp.MatchString(text)
+}
+
+// Resolves a free-text location to a structured campus location, reporting false if it couldn't be resolved
+func ParseLocation(text string) (schema.Location, bool) {
+	text = TrimWhitespace(text)
+
+	// Try strict building + room forms first
+	if submatches := primaryLocationRegexp.FindStringSubmatch(text); submatches != nil {
+		return NewLocation(submatches[1], submatches[2]), true
+	}
+	if submatches := fallbackLocationRegexp.FindStringSubmatch(text); submatches != nil {
+		return NewLocation(submatches[1], fmt.Sprintf("%s.%s", submatches[2], submatches[3])), true
+	}
+
+	// Otherwise, look for a known building name or abbreviation somewhere in the text
+	building, remainder, found := findBuilding(text)
+	if !found {
+		return schema.Location{}, false
+	}
+
+	// Prefer a room number if there is one, otherwise treat whatever's left as a named room
+	room := ""
+	if submatches := roomNumberRegexp.FindStringSubmatch(remainder); submatches != nil {
+		room = submatches[1]
+	} else {
+		room = locationSeparatorRegexp.ReplaceAllString(remainder, "")
+	}
+	return NewLocation(building, room), true
+}
+
+// Makes a location for the given building and room, linking to the campus locator
+func NewLocation(building string, room string) schema.Location {
+	mapURI := fmt.Sprintf("https://locator.utdallas.edu/%s", building)
+	// Named rooms aren't known to the locator, so only link directly to numbered rooms
+	if roomNumberRegexp.MatchString(room) {
+		mapURI = fmt.Sprintf("https://locator.utdallas.edu/%s_%s", building, room)
+	}
+	return schema.Location{
+		Building: building,
+		Room:     room,
+		Map_uri:  mapURI,
+	}
+}
+
+// Finds the building referenced in the text, returning its abbreviation and the text with the building reference removed
+func findBuilding(text string) (string, string, bool) {
+	for i, alias := range sortedAliases {
+		// Aliases must appear as whole words, so short aliases don't match inside longer words
+		match := aliasRegexps[i].FindStringIndex(text)
+		if match == nil {
+			continue
+		}
+		// Venue names are also the room, so keep the venue name and anything after it
+		if building, isVenue := venueAliases[alias]; isVenue {
+			return building, text[match[0]:], true
+		}
+		return buildingAliases[alias], TrimWhitespace(text[:match[0]] + " " + text[match[1]:]), true
+	}
+	// Abbreviations must appear as their own uppercase word followed by a room number, i.e. "Meet in SU 2.602",
+	// since short codes like "SU" or "CB" are also ordinary words or initials
+	for _, match := range abbreviationRegexp.FindAllStringIndex(text, -1) {
+		if word := text[match[0]:match[1]]; buildingCodes[word] && leadingRoomNumberRegexp.MatchString(text[match[1]:]) {
+			return word, TrimWhitespace(text[:match[0]] + " " + text[match[1]:]), true
+		}
+	}
+	return "", "", false
+}
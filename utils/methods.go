@@ -6,6 +6,7 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,6 +22,7 @@ import (
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Initializes Chrome DevTools Protocol
@@ -184,8 +186,10 @@ func WriteJSON(filepath string, data interface{}) error {
 	defer fptr.Close()
 	encoder := json.NewEncoder(fptr)
 	encoder.SetIndent("", "\t")
-	encoder.Encode(data)
-	return nil
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
+	return fptr.Close()
 }
 
 // Recursively gets the filepath of every file with the given extension, using the given directory as the root.
@@ -207,6 +211,15 @@ func GetAllFilesWithExtension(inDir string, extension string) []string {
 	return filePaths
 }
 
+// Derives an ObjectID from the given kind of document and its natural key, so the same document always gets the same ID across runs.
+// Key parts are separated by a null byte so that e.g. ("ab", "c") and ("a", "bc") produce different IDs.
+func DeterministicID(kind string, keyParts ...string) primitive.ObjectID {
+	hash := sha256.Sum256([]byte(kind + "\x00" + strings.Join(keyParts, "\x00")))
+	var id primitive.ObjectID
+	copy(id[:], hash[:len(id)])
+	return id
+}
+
 // Removes standard whitespace characters (space, tab, newline, carriage return) from a given string.
 func TrimWhitespace(text string) string {
 	return strings.Trim(text, " \t\n\r")